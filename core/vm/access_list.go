@@ -0,0 +1,54 @@
+package vm
+
+import (
+	"encoding/binary"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+)
+
+// AccessTuple is the element type of an access list (EIP-2930).
+type AccessTuple struct {
+	Address     common.Address
+	StorageKeys []common.Hash
+}
+
+// AccessList is an EIP-2930 access list.
+type AccessList []AccessTuple
+
+// StorageKeys returns the total number of storage keys in the access list.
+func (al AccessList) StorageKeys() (sum int) {
+	for _, tuple := range al {
+		sum += len(tuple.StorageKeys)
+	}
+	return
+}
+
+// prepareAccessList warms up the addresses and slots which are accessible for free
+// at the beginning of a transaction according to EIP-2929 and EIP-2930:
+// - sender, destination (if present) and all precompiles
+// - every address and slot from the transaction access list
+func (self *EVM) prepareAccessList(sender, dst *common.Address, list AccessList) {
+	self.state.PrepareAccessList()
+	self.state.AddAddressToAccessList(sender)
+	if dst != nil {
+		self.state.AddAddressToAccessList(dst)
+	}
+	for i, contract := range self.precompiles {
+		if contract != nil {
+			self.state.AddAddressToAccessList(precompileAddress(i))
+		}
+	}
+	for _, el := range list {
+		self.state.AddAddressToAccessList(&el.Address)
+		for i := range el.StorageKeys {
+			self.state.AddSlotToAccessList(&el.Address, &el.StorageKeys[i])
+		}
+	}
+}
+
+// precompileAddress returns the address of the precompile at the given position of Precompiles
+func precompileAddress(pos int) *common.Address {
+	var address common.Address
+	binary.BigEndian.PutUint16(address[common.AddressLength-2:], uint16(pos+1))
+	return &address
+}
@@ -0,0 +1,274 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/common/hexutil"
+)
+
+const CallTracerName = "callTracer"
+
+type CallTracerConfig struct {
+	OnlyTopCall bool `json:"onlyTopCall"` // If true, call tracer won't collect any subcalls
+	WithLog     bool `json:"withLog"`     // If true, call tracer will collect event logs
+}
+
+type callLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+	// Position of the log relative to subcalls within the same trace
+	Position hexutil.Uint `json:"position"`
+}
+
+// callFrame is the geth callTracer frame, the fields are ordered as in its JSON
+type callFrame struct {
+	Type         OpCode          `json:"-"`
+	From         common.Address  `json:"from"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	To           *common.Address `json:"to,omitempty"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []callFrame     `json:"calls,omitempty"`
+	Logs         []callLog       `json:"logs,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	TypeString   string          `json:"type"`
+}
+
+func (self *callFrame) failed() bool {
+	return len(self.Error) > 0
+}
+
+func (self *callFrame) processOutput(output []byte, err error) {
+	output = common.CopyBytes(output)
+	if err == nil {
+		self.Output = output
+		return
+	}
+	self.Error = err.Error()
+	if self.Type == CREATE || self.Type == CREATE2 {
+		self.To = nil
+	}
+	if err != ErrExecutionReverted || len(output) == 0 {
+		return
+	}
+	self.Output = output
+	if reason, unpacked := unpackRevert(output); unpacked {
+		self.RevertReason = reason
+	}
+}
+
+// CallTracer is the native implementation of the geth callTracer, it collects the nested call frames of a transaction
+type CallTracer struct {
+	callstack []callFrame
+	config    CallTracerConfig
+	trx       *Transaction
+}
+
+func NewCallTracer(cfg json.RawMessage) (*CallTracer, error) {
+	self := &CallTracer{callstack: make([]callFrame, 0, 1)}
+	if len(cfg) != 0 {
+		if err := json.Unmarshal(cfg, &self.config); err != nil {
+			return nil, err
+		}
+	}
+	return self, nil
+}
+
+func (self *CallTracer) CaptureTxStart(env *EVM, trx *Transaction) error {
+	self.trx = trx
+	return nil
+}
+
+func (self *CallTracer) CaptureStart(env *EVM, from *common.Address, to *common.Address, precompile bool, create bool, input []byte, gas uint64, value *big.Int, code []byte) error {
+	typ := CALL
+	if create {
+		typ = CREATE
+	}
+	self.callstack = append(self.callstack, callFrame{
+		Type:  typ,
+		From:  *from,
+		To:    copy_address_ptr(to),
+		Input: common.CopyBytes(input),
+		Gas:   hexutil.Uint64(self.trx.Gas),
+		Value: (*hexutil.Big)(value),
+	})
+	return nil
+}
+
+func (self *CallTracer) CaptureEnter(typ OpCode, from *common.Address, to *common.Address, precompile bool, create bool, input []byte, gas uint64, value *big.Int, code []byte) error {
+	if self.config.OnlyTopCall || len(self.callstack) == 0 {
+		return nil
+	}
+	switch typ {
+	case DELEGATECALL:
+		// DELEGATECALL inherits the value of the parent call
+		value = (*big.Int)(self.callstack[len(self.callstack)-1].Value)
+	case STATICCALL:
+		value = nil
+	}
+	self.callstack = append(self.callstack, callFrame{
+		Type:  typ,
+		From:  *from,
+		To:    copy_address_ptr(to),
+		Input: common.CopyBytes(input),
+		Gas:   hexutil.Uint64(gas),
+		Value: (*hexutil.Big)(value),
+	})
+	return nil
+}
+
+func (self *CallTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth uint16, err error) error {
+	// Only logs need to be captured via opcode processing, the nested calls are skipped for the top call only
+	if err != nil || !self.config.WithLog || self.config.OnlyTopCall && depth > 1 || op < LOG0 || op > LOG4 {
+		return nil
+	}
+	size := int(op - LOG0)
+	m_start, m_size := stack.Back(0), stack.Back(1)
+	topics := make([]common.Hash, size)
+	for i := 0; i < size; i++ {
+		topics[i] = common.Hash(stack.Back(2 + i).Bytes32())
+	}
+	frame := &self.callstack[len(self.callstack)-1]
+	frame.Logs = append(frame.Logs, callLog{
+		Address:  *contract.Address(),
+		Topics:   topics,
+		Data:     memory.GetCopy(int64(m_start.Uint64()), int64(m_size.Uint64())),
+		Position: hexutil.Uint(len(frame.Calls)),
+	})
+	return nil
+}
+
+func (self *CallTracer) CaptureExit(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	if self.config.OnlyTopCall || len(self.callstack) <= 1 {
+		return nil
+	}
+	call := self.callstack[len(self.callstack)-1]
+	self.callstack = self.callstack[:len(self.callstack)-1]
+	call.GasUsed = hexutil.Uint64(gasUsed)
+	call.processOutput(output, err)
+	parent := &self.callstack[len(self.callstack)-1]
+	parent.Calls = append(parent.Calls, call)
+	return nil
+}
+
+func (self *CallTracer) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	if len(self.callstack) != 0 {
+		self.callstack[0].processOutput(output, err)
+	}
+	return nil
+}
+
+func (self *CallTracer) CaptureTxEnd(result *ExecutionResult) error {
+	if len(self.callstack) == 0 {
+		// The transaction was rejected before the execution, so the top call is reported with the consensus error
+		typ := CALL
+		if self.trx.To == nil {
+			typ = CREATE
+		}
+		self.callstack = append(self.callstack, callFrame{
+			Type:  typ,
+			From:  self.trx.From,
+			To:    copy_address_ptr(self.trx.To),
+			Input: common.CopyBytes(self.trx.Input),
+			Gas:   hexutil.Uint64(self.trx.Gas),
+			Value: (*hexutil.Big)(self.trx.Value),
+			Error: string(result.ConsensusErr),
+		})
+	}
+	self.callstack[0].GasUsed = hexutil.Uint64(result.GasUsed)
+	if self.config.WithLog {
+		// Logs are not emitted when the call fails
+		clearFailedLogs(&self.callstack[0], false)
+	}
+	return nil
+}
+
+// GetResult returns the JSON of the top call frame
+func (self *CallTracer) GetResult() (json.RawMessage, error) {
+	if len(self.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	fill_type_strings(&self.callstack[0])
+	return json.Marshal(&self.callstack[0])
+}
+
+func copy_address_ptr(addr *common.Address) *common.Address {
+	if addr == nil {
+		return nil
+	}
+	cpy := *addr
+	return &cpy
+}
+
+func fill_type_strings(frame *callFrame) {
+	frame.TypeString = frame.Type.String()
+	for i := range frame.Calls {
+		fill_type_strings(&frame.Calls[i])
+	}
+}
+
+// clearFailedLogs clears the logs of the failed call frames and of their subcalls
+func clearFailedLogs(frame *callFrame, parent_failed bool) {
+	failed := frame.failed() || parent_failed
+	if failed {
+		frame.Logs = nil
+	}
+	for i := range frame.Calls {
+		clearFailedLogs(&frame.Calls[i], failed)
+	}
+}
+
+var (
+	revertSelector = common.FromHex("0x08c379a0") // Error(string)
+	panicSelector  = common.FromHex("0x4e487b71") // Panic(uint256)
+	panicReasons   = map[uint64]string{
+		0x00: "generic panic",
+		0x01: "assert(false)",
+		0x11: "arithmetic underflow or overflow",
+		0x12: "division or modulo by zero",
+		0x21: "enum overflow",
+		0x22: "invalid encoded storage byte array accessed",
+		0x31: "out-of-bounds array access; popping on an empty array",
+		0x32: "out-of-bounds access of an array or bytesN",
+		0x41: "out of memory",
+		0x51: "uninitialized function",
+	}
+)
+
+// unpackRevert decodes the solidity Error(string) and Panic(uint256) revert data
+func unpackRevert(data []byte) (string, bool) {
+	if len(data) < 4+32 {
+		return "", false
+	}
+	selector, args := data[:4], data[4:]
+	if bytes.Equal(selector, panicSelector) {
+		code := new(big.Int).SetBytes(args[:32])
+		if reason, known := panicReasons[code.Uint64()]; known && code.IsUint64() {
+			return reason, true
+		}
+		return fmt.Sprintf("unknown panic code: %#x", code), true
+	}
+	if !bytes.Equal(selector, revertSelector) || len(args) < 64 {
+		return "", false
+	}
+	offset := new(big.Int).SetBytes(args[:32])
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(args)-32) {
+		return "", false
+	}
+	str := args[offset.Uint64():]
+	length := new(big.Int).SetBytes(str[:32])
+	if !length.IsUint64() || length.Uint64() > uint64(len(str)-32) {
+		return "", false
+	}
+	return string(str[32 : 32+length.Uint64()]), true
+}
@@ -57,11 +57,36 @@ const (
 	MemoryGas        uint64 = 3               // Times the address of the (highest referenced byte in memory + 1). NOTE: referencing happens on read, write and in instructions such as RETURN and CALL.
 	TxDataNonZeroGas uint64 = 68              // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
 
+	SstoreSentryGasEIP2200            uint64 = 2300  // Minimum gas required to be present for an SSTORE call, not consumed
+	SstoreSetGasEIP2200               uint64 = 20000 // Once per SSTORE operation from clean zero to non-zero
+	SstoreResetGasEIP2200             uint64 = 5000  // Once per SSTORE operation from clean non-zero to something else
+	SstoreClearsScheduleRefundEIP2200 uint64 = 15000 // Once per SSTORE operation for clearing an originally existing storage slot
+
+	ColdAccountAccessCostEIP2929 uint64 = 2600 // COLD_ACCOUNT_ACCESS_COST
+	ColdSloadCostEIP2929         uint64 = 2100 // COLD_SLOAD_COST
+	WarmStorageReadCostEIP2929   uint64 = 100  // WARM_STORAGE_READ_COST
+
+	TxAccessListAddressGas    uint64 = 2400 // Per address specified in EIP 2930 access list
+	TxAccessListStorageKeyGas uint64 = 1900 // Per storage key specified in EIP 2930 access list
+
+	// Once per SSTORE operation for clearing an originally existing storage slot since EIP-3529
+	SstoreClearsScheduleRefundEIP3529 uint64 = SstoreResetGasEIP2200 - ColdSloadCostEIP2929 + TxAccessListStorageKeyGas
+
+	RefundQuotient        uint64 = 2 // Maximum refund is the used gas divided by RefundQuotient
+	RefundQuotientEIP3529 uint64 = 5 // Maximum refund is the used gas divided by RefundQuotientEIP3529 since EIP-3529
+
+	MaxInitCodeSize        = 2 * MaxCodeSize // Maximum initcode to permit in a creation transaction and create instructions (EIP-3860)
+	InitCodeWordGas uint64 = 2               // Once per word of the initcode of a creation transaction and create instructions (EIP-3860)
+
+	TxAuthTupleGas     uint64 = CallNewAccountGas // Per authorization of a set code transaction, PER_EMPTY_ACCOUNT_COST (EIP-7702)
+	TxAuthTupleBaseGas uint64 = 12500             // PER_AUTH_BASE_COST, the rest is refunded if the authority exists (EIP-7702)
+
 	MaxCodeSize = 24576 // Maximum bytecode to permit for a contract
 
 	// Precompiled contract gas prices
 
 	EcrecoverGas            uint64 = 3000   // Elliptic curve sender recovery gas price
+	P256VerifyGas           uint64 = 3450   // secp256r1 signature verification gas price (RIP-7212)
 	Sha256BaseGas           uint64 = 60     // Base price for a SHA256 operation
 	Sha256PerWordGas        uint64 = 12     // Per-word price for a SHA256 operation
 	Ripemd160BaseGas        uint64 = 600    // Base price for a RIPEMD160 operation
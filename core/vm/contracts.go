@@ -18,6 +18,8 @@ package vm
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
@@ -46,27 +48,30 @@ type PrecompiledContract interface {
 	Run(ctx CallFrame, evm *EVM) ([]byte, error)
 }
 
-type Precompiles [255]PrecompiledContract
+// Precompiles are indexed by the address - 1, the addresses are 0x01...0x100 (RIP-7212 P256VERIFY is at 0x100)
+type Precompiles [256]PrecompiledContract
 
-var PrecompiledContractAddrPrefix = make([]byte, common.AddressLength-1)
+var PrecompiledContractAddrPrefix = make([]byte, common.AddressLength-2)
 
-func (self *Precompiles) Get(address *common.Address) (ret PrecompiledContract) {
-	last_byte := address[common.AddressLength-1]
-	if last_byte == 0 {
+// precompilePos returns the position of the address in Precompiles
+func precompilePos(address *common.Address) (pos int, ok bool) {
+	num := int(binary.BigEndian.Uint16(address[common.AddressLength-2:]))
+	if num == 0 || num > len(Precompiles{}) || !bytes.Equal(address[:common.AddressLength-2], PrecompiledContractAddrPrefix) {
 		return
 	}
-	ret = self[last_byte-1]
-	if ret != nil && !bytes.Equal(address[:common.AddressLength-1], PrecompiledContractAddrPrefix) {
-		ret = nil
+	return num - 1, true
+}
+
+func (self *Precompiles) Get(address *common.Address) (ret PrecompiledContract) {
+	if pos, ok := precompilePos(address); ok {
+		ret = self[pos]
 	}
 	return
 }
 
 func (self *Precompiles) Put(address *common.Address, contract PrecompiledContract) {
-	asserts.Holds(bytes.Equal(address[:common.AddressLength-1], PrecompiledContractAddrPrefix))
-	last_addr_byte := address[common.AddressLength-1]
-	asserts.Holds(last_addr_byte != 0)
-	pos := last_addr_byte - 1
+	pos, ok := precompilePos(address)
+	asserts.Holds(ok)
 	asserts.Holds(self[pos] == nil)
 	self[pos] = contract
 }
@@ -106,6 +111,15 @@ var PrecompiledContractsFicus = Precompiles{
 	&bls12381MapG2{},      // Position 19, address 0x13
 }
 
+// PrecompiledContractsViola adds P256VERIFY (RIP-7212) at the address 0x100 to PrecompiledContractsFicus
+var PrecompiledContractsViola = newPrecompiledContractsViola()
+
+func newPrecompiledContractsViola() Precompiles {
+	precompiles := PrecompiledContractsFicus
+	precompiles.Put(&common.Address{18: 0x01}, &p256Verify{}) // Position 256, address 0x100
+	return precompiles
+}
+
 // ECRECOVER implemented as a native contract.
 type ecrecover struct{}
 
@@ -951,3 +965,28 @@ func (c bls12381MapG2) Run(ctx CallFrame, evm *EVM) ([]byte, error) {
 	// Encode the G2 point to 256 bytes
 	return encodePointG2(&r), nil
 }
+
+// P256VERIFY implemented as a native contract (RIP-7212), verifies the secp256r1 (P-256) signature of the hash
+type p256Verify struct{}
+
+func (c *p256Verify) RequiredGas(ctx CallFrame, evm *EVM) uint64 {
+	return P256VerifyGas
+}
+
+func (c *p256Verify) Run(ctx CallFrame, evm *EVM) ([]byte, error) {
+	const p256VerifyInputLength = 160
+	// "input" is (hash, r, s, x, y), each 32 bytes. The result is empty if the signature is invalid
+	input := ctx.Input
+	if len(input) != p256VerifyInputLength {
+		return nil, nil
+	}
+	r, s := new(big.Int).SetBytes(input[32:64]), new(big.Int).SetBytes(input[64:96])
+	x, y := new(big.Int).SetBytes(input[96:128]), new(big.Int).SetBytes(input[128:160])
+	if !elliptic.P256().IsOnCurve(x, y) {
+		return nil, nil
+	}
+	if !ecdsa.Verify(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, input[:32], r, s) {
+		return nil, nil
+	}
+	return common.LeftPadBytes([]byte{1}, 32), nil
+}
@@ -41,7 +41,7 @@ type precompiledFailureTest struct {
 	Name          string
 }
 
-var allPrecompiles = PrecompiledContractsFicus
+var allPrecompiles = PrecompiledContractsViola
 
 // EIP-152 test vectors
 var blake2FMalformedinputTests = []precompiledFailureTest{
@@ -238,6 +238,24 @@ func TestPrecompileBlake2FMalformedinput(t *testing.T) {
 
 func TestPrecompiledEcrecover(t *testing.T) { testJson("ecRecover", "01", t) }
 
+func TestPrecompiledP256Verify(t *testing.T) {
+	testJson("p256Verify", "0100", t)
+	// the two byte addresses don't collide with the one byte ones
+	if ecrecover_addr := (common.Address{19: 0x01}); allPrecompiles.Get(&ecrecover_addr) == nil {
+		t.Error("no precompile at 0x01")
+	}
+	for _, addr := range []common.Address{{17: 0x01}, {18: 0x01, 19: 0x01}} {
+		if allPrecompiles.Get(&addr) != nil {
+			t.Errorf("unexpected precompile at %x", addr)
+		}
+	}
+	if addr := common.HexToAddress("0100"); PrecompiledContractsFicus.Get(&addr) != nil {
+		t.Error("P256VERIFY is in the Ficus precompiles")
+	}
+}
+
+func BenchmarkPrecompiledP256Verify(b *testing.B) { benchJson("p256Verify", "0100", b) }
+
 func testJson(name, addr string, t *testing.T) {
 	tests, err := loadJson(name)
 	if err != nil {
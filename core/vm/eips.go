@@ -1,5 +1,7 @@
 package vm
 
+import "github.com/holiman/uint256"
+
 // enable5656 enables EIP-5656 (MCOPY opcode)
 // https://eips.ethereum.org/EIPS/eip-5656
 func enable5656(jt *InstructionSet) {
@@ -23,3 +25,72 @@ func opMcopy(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *St
 	memory.Copy(dst.Uint64(), src.Uint64(), length.Uint64())
 	return nil, nil
 }
+
+// enable2929 enables "EIP-2929: Gas cost increases for state access opcodes"
+// https://eips.ethereum.org/EIPS/eip-2929
+func enable2929(jt *InstructionSet) {
+	jt[SSTORE].gasCost = gasSStoreEIP2929
+	jt[SLOAD].gasCost = gasSLoadEIP2929
+	jt[EXTCODECOPY].gasCost = gasExtCodeCopyEIP2929
+	jt[EXTCODESIZE].gasCost = gasExtCodeSizeEIP2929
+	jt[EXTCODEHASH].gasCost = gasExtCodeHashEIP2929
+	jt[BALANCE].gasCost = gasBalanceEIP2929
+	jt[CALL].gasCost = gasCallEIP2929
+	jt[CALLCODE].gasCost = gasCallCodeEIP2929
+	jt[STATICCALL].gasCost = gasStaticCallEIP2929
+	jt[DELEGATECALL].gasCost = gasDelegateCallEIP2929
+	jt[SELFDESTRUCT].gasCost = gasSuicideEIP2929
+}
+
+// enable3529 enables "EIP-3529: Reduction in refunds", SELFDESTRUCT refund is removed and SSTORE clearing
+// refund is reduced. Requires EIP-2929
+// https://eips.ethereum.org/EIPS/eip-3529
+func enable3529(jt *InstructionSet) {
+	jt[SSTORE].gasCost = gasSStoreEIP3529
+	jt[SELFDESTRUCT].gasCost = gasSuicideEIP3529
+}
+
+// enable3860 enables "EIP-3860: Limit and meter initcode" for the create instructions, creation transactions
+// are checked in EVM.Main
+// https://eips.ethereum.org/EIPS/eip-3860
+func enable3860(jt *InstructionSet) {
+	jt[CREATE].gasCost = gasCreateEIP3860
+	jt[CREATE2].gasCost = gasCreate2EIP3860
+}
+
+// enable7702 enables "EIP-7702: Set EOA account code", the calls charge the access to the account which the callee
+// delegates to. The authorizations are applied in EVM.Main and the delegated code is resolved in EVM.call
+// https://eips.ethereum.org/EIPS/eip-7702
+func enable7702(jt *InstructionSet) {
+	jt[CALL].gasCost = gasCallEIP7702
+	jt[CALLCODE].gasCost = gasCallCodeEIP7702
+	jt[STATICCALL].gasCost = gasStaticCallEIP7702
+	jt[DELEGATECALL].gasCost = gasDelegateCallEIP7702
+}
+
+// enable6780 enables "EIP-6780: SELFDESTRUCT only in same transaction", the account is deleted only if it was
+// created in the current transaction, otherwise only the balance is sent to the beneficiary
+// https://eips.ethereum.org/EIPS/eip-6780
+func enable6780(jt *InstructionSet) {
+	jt[SELFDESTRUCT].execute = opSuicide6780
+}
+
+// enable3198 applies EIP-3198 (BASEFEE Opcode)
+// https://eips.ethereum.org/EIPS/eip-3198
+func enable3198(jt *InstructionSet) {
+	jt[BASEFEE] = &operation{
+		execute:       opBaseFee,
+		gasCost:       constGasFunc(GasQuickStep),
+		validateStack: makeStackFunc(0, 1),
+	}
+}
+
+// opBaseFee implements BASEFEE opcode
+func opBaseFee(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	base_fee := new(uint256.Int)
+	if evm.block.BaseFee != nil {
+		base_fee.SetFromBig(evm.block.BaseFee)
+	}
+	stack.push(base_fee)
+	return nil, nil
+}
@@ -37,4 +37,18 @@ var (
 	ErrReturnDataOutOfBounds          = errors.New("return data out of bounds")
 	ErrExecutionReverted              = errors.New("execution reverted")
 	ErrMaxCodeSizeExceeded            = errors.New("max code size exceeded")
+	ErrMaxInitCodeSizeExceeded        = errors.New("max initcode size exceeded")
+	ErrSStoreSentry                   = errors.New("not enough gas for reentrancy sentry")
+	ErrFeeCapTooLow                   = errors.New("max fee per gas less than block base fee")
+	ErrTipAboveFeeCap                 = errors.New("max priority fee per gas higher than max fee per gas")
+	ErrGasPriceTooLow                 = errors.New("gas price lower than minimum")
+	ErrGasLimitTooHigh                = errors.New("gas limit higher than maximum")
+	ErrBlockGasLimitReached           = errors.New("block gas limit reached")
+	ErrSetCodeTxCreate                = errors.New("set code transaction can't create a contract")
+	// Reasons to skip a set code transaction authorization (EIP-7702)
+	ErrAuthorizationWrongChainID       = errors.New("authorization chain id mismatch")
+	ErrAuthorizationNonceOverflow      = errors.New("authorization nonce overflow")
+	ErrAuthorizationInvalidSignature   = errors.New("authorization has invalid signature")
+	ErrAuthorizationDestinationHasCode = errors.New("authorization destination has code")
+	ErrAuthorizationNonceMismatch      = errors.New("authorization nonce does not match")
 )
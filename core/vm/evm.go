@@ -39,6 +39,8 @@ type Config struct {
 	Debug bool
 	// Tracer is the op code logger
 	Tracer Tracer
+	// NoBaseFee allows zero gas price calls even if the block base fee is not zero (EIP-1559)
+	NoBaseFee bool
 }
 
 // EVM is the Ethereum Virtual Machine base object and provides
@@ -61,6 +63,7 @@ type EVM struct {
 	instruction_set   InstructionSet
 	gas_table         GasTable
 	trx               *Transaction
+	gas_price         *big.Int // effective gas price of the current transaction, provides information for GASPRICE
 	depth             uint16
 	// tech stuff
 	mem_pool  MemoryPool
@@ -91,6 +94,19 @@ type Rules struct {
 	IsAspenPartTwo bool
 	IsFicus        bool
 	IsCornus       bool
+	IsBerberis     bool
+	IsLantana      bool
+	IsRosa         bool
+	IsSalvia       bool
+	IsTilia        bool
+	IsProtea       bool
+	// Transaction and block gas limits enforced since Protea HF. Zero means there is no limit
+	TrxMinGasPrice uint64
+	TrxMaxGasLimit uint64
+	DagGasLimit    uint64 // transaction can't use more gas than a DAG block
+	PbftGasLimit   uint64
+	// Instruction set, gas table and precompiled contracts, SpecCalifornicum is used for the unset parts
+	Spec Spec
 }
 
 type Block struct {
@@ -102,15 +118,22 @@ type BlockInfo struct {
 	GasLimit   uint64         // Provides information for GASLIMIT
 	Time       uint64         // Provides information for TIME
 	Difficulty *big.Int       // Provides information for DIFFICULTY
+	BaseFee    *big.Int       `rlp:"optional"` // Provides information for BASEFEE (EIP-1559)
 }
 type Transaction struct {
-	From     common.Address  // Provides information for ORIGIN
-	GasPrice *big.Int        // Provides information for GASPRICE
-	To       *common.Address `rlp:"nil"`
-	Nonce    *big.Int
-	Value    *big.Int
-	Gas      uint64
-	Input    []byte
+	From       common.Address  // Provides information for ORIGIN
+	GasPrice   *big.Int        // Provides information for GASPRICE
+	To         *common.Address `rlp:"nil"`
+	Nonce      *big.Int
+	Value      *big.Int
+	Gas        uint64
+	Input      []byte
+	AccessList AccessList `rlp:"optional"` // EIP-2930, taken into account since Berberis HF
+	// EIP-1559 dynamic fee fields, taken into account since Lantana HF. GasPrice is ignored if MaxFeePerGas is set
+	MaxFeePerGas         *big.Int `rlp:"optional"`
+	MaxPriorityFeePerGas *big.Int `rlp:"optional"`
+	// EIP-7702 set code authorizations, taken into account since Tilia HF
+	AuthorizationList []SetCodeAuthorization `rlp:"optional"`
 }
 type ExecutionResult struct {
 	CodeRetval      []byte
@@ -119,6 +142,14 @@ type ExecutionResult struct {
 	GasUsed         uint64
 	ExecutionErr    util.ErrorString
 	ConsensusErr    util.ErrorString
+	// EffectiveTip is the part of the gas price which goes to validators, the rest (block base fee) is burned (EIP-1559).
+	// Before Lantana HF it is the whole gas price. Nil, encoded as zero, only if the gas price is invalid and nothing is
+	// paid
+	EffectiveTip *big.Int `rlp:"optional"`
+	// CumulativeGasUsed is the gas used in the block up to and including this transaction
+	CumulativeGasUsed uint64 `rlp:"optional"`
+	// GasPrice is the price per gas deducted from the sender balance, zero for zero price calls. Not encoded
+	GasPrice *big.Int `rlp:"-"`
 }
 
 func (self *EVM) Init(get_hash GetHashFunc, state State, opts Opts, chainConfig params.ChainConfig, vmConfig Config) *EVM {
@@ -159,20 +190,22 @@ func (self *EVM) SetBlock(blk *Block, rules Rules) (rules_changed bool) {
 	} else {
 		self.rules_initialized = true
 	}
-	switch {
-	case rules.IsFicus:
-		self.precompiles = PrecompiledContractsFicus
-		self.instruction_set = ficusInstructionSet
-		self.gas_table = GasTableCalifornicum
-	default:
-		self.precompiles = PrecompiledContractsCalifornicum
-		self.instruction_set = californicumInstructionSet
-		self.gas_table = GasTableCalifornicum
-	}
+	spec := SpecCalifornicum
+	spec.Override(&rules.Spec)
+	self.precompiles = *spec.Precompiles
+	self.instruction_set = *spec.InstructionSet
+	self.gas_table = *spec.GasTable
 	self.rules = rules
 	return true
 }
 
+// ResetPrecompiledContracts drops the registered precompiled contracts, so that they can be registered again
+func (self *EVM) ResetPrecompiledContracts() {
+	spec := SpecCalifornicum
+	spec.Override(&self.rules.Spec)
+	self.precompiles = *spec.Precompiles
+}
+
 func (self *EVM) RegisterPrecompiledContract(address *common.Address, contract PrecompiledContract) {
 	self.precompiles.Put(address, contract)
 }
@@ -187,12 +220,24 @@ func consensusErr(result ExecutionResult, trxGas uint64, consensusErr error) (re
 func (self *EVM) Main(trx *Transaction) (ret ExecutionResult, execError error) {
 	self.trx = trx
 
-	defer func() { self.trx, self.jumpdests = nil, nil }()
+	defer func() { self.trx, self.gas_price, self.jumpdests = nil, nil, nil }()
+	if self.vmConfig.Debug {
+		self.vmConfig.Tracer.CaptureTxStart(self, trx)
+		defer func() { self.vmConfig.Tracer.CaptureTxEnd(&ret) }()
+	}
 
 	caller := self.state.GetAccount(&self.trx.From)
 	sender_nonce := caller.GetNonce()
 
-	gas_cap, gas_price := self.trx.Gas, self.trx.GasPrice
+	gas_cap := self.trx.Gas
+	gas_price, tip, err := self.effectiveGasPrice()
+	if err != nil {
+		if self.rules.IsCornus && self.trx.Nonce.Cmp(sender_nonce) >= 0 {
+			caller.SetNonce(bigutil.Add(self.trx.Nonce, big.NewInt(1)))
+		}
+		return consensusErr(ret, 0, err)
+	}
+	self.gas_price, ret.GasPrice, ret.EffectiveTip = gas_price, gas_price, tip
 	gas_fee := new(big.Int).Mul(new(big.Int).SetUint64(gas_cap), gas_price)
 	contract_creation := self.trx.To == nil
 
@@ -222,7 +267,28 @@ func (self *EVM) Main(trx *Transaction) (ret ExecutionResult, execError error) {
 	// 	return
 	// }
 
-	gas_intrinsic, err := IntrinsicGas(self.trx.Input, contract_creation)
+	if err := self.checkTrxLimits(gas_price); err != nil {
+		if self.rules.IsCornus {
+			caller.SetNonce(bigutil.Add(self.trx.Nonce, big.NewInt(1)))
+		}
+		return consensusErr(ret, gas_cap, err)
+	}
+
+	var access_list AccessList
+	if self.rules.IsBerberis {
+		access_list = self.trx.AccessList
+	}
+	var auth_list []SetCodeAuthorization
+	if self.rules.IsTilia {
+		auth_list = self.trx.AuthorizationList
+	}
+	gas_intrinsic, err := IntrinsicGas(self.trx.Input, access_list, auth_list, contract_creation, self.rules.IsRosa)
+	if err == nil && contract_creation && self.rules.IsRosa && len(self.trx.Input) > MaxInitCodeSize {
+		err = ErrMaxInitCodeSizeExceeded
+	}
+	if err == nil && contract_creation && len(auth_list) != 0 {
+		err = ErrSetCodeTxCreate
+	}
 	if err != nil {
 		if self.rules.IsCornus {
 			caller.SetNonce(bigutil.Add(self.trx.Nonce, big.NewInt(1)))
@@ -240,6 +306,10 @@ func (self *EVM) Main(trx *Transaction) (ret ExecutionResult, execError error) {
 	gas_left := gas_cap
 	gas_left -= gas_intrinsic
 
+	if self.rules.IsBerberis {
+		self.prepareAccessList(&self.trx.From, self.trx.To, access_list)
+	}
+
 	if contract_creation {
 		// setting nonce to current trx nonce to generate correct address for new contract. Nonce incremented inside of `create` later
 		caller.SetNonce(self.trx.Nonce)
@@ -247,6 +317,13 @@ func (self *EVM) Main(trx *Transaction) (ret ExecutionResult, execError error) {
 	} else {
 		acc_to := self.state.GetAccount(self.trx.To)
 		caller.SetNonce(bigutil.Add(self.trx.Nonce, big.NewInt(1)))
+		if self.rules.IsTilia {
+			// Authorizations are applied after the sender nonce increment and are kept if the execution fails
+			self.applyAuthorizations(auth_list)
+			if target, delegated := ParseDelegation(acc_to.GetCode()); delegated {
+				self.state.AddAddressToAccessList(&target)
+			}
+		}
 		ret.CodeRetval, gas_left, err = self.Call(ContractAccWrapper{caller}, acc_to, self.trx.Input, gas_left, self.trx.Value)
 	}
 	if err != nil {
@@ -257,7 +334,11 @@ func (self *EVM) Main(trx *Transaction) (ret ExecutionResult, execError error) {
 			execError = err
 		}
 	}
-	gas_left += util.MinU64(self.state.GetRefund(), (gas_cap-gas_left)/2)
+	refund_quotient := RefundQuotient
+	if self.rules.IsRosa {
+		refund_quotient = RefundQuotientEIP3529
+	}
+	gas_left += util.MinU64(self.state.GetRefund(), (gas_cap-gas_left)/refund_quotient)
 	ret.GasUsed = gas_cap - gas_left
 	ret.Logs = self.state.GetLogs()
 	// Return ETH for remaining gas, exchanged at the original rate.
@@ -265,10 +346,69 @@ func (self *EVM) Main(trx *Transaction) (ret ExecutionResult, execError error) {
 	return
 }
 
+// checkTrxLimits validates the transaction gas price and gas limit against the limits enforced since Protea HF.
+// System transactions are not limited, zero price calls are allowed in dry runs
+func (self *EVM) checkTrxLimits(gas_price *big.Int) error {
+	if !self.rules.IsProtea || self.trx.From == common.ZeroAddress {
+		return nil
+	}
+	zero_price_allowed := self.vmConfig.NoBaseFee && gas_price.Sign() == 0
+	if self.rules.TrxMinGasPrice != 0 && !zero_price_allowed && gas_price.Cmp(new(big.Int).SetUint64(self.rules.TrxMinGasPrice)) < 0 {
+		return ErrGasPriceTooLow
+	}
+	if self.rules.TrxMaxGasLimit != 0 && self.trx.Gas > self.rules.TrxMaxGasLimit {
+		return ErrGasLimitTooHigh
+	}
+	if self.rules.DagGasLimit != 0 && self.trx.Gas > self.rules.DagGasLimit {
+		return ErrGasLimitTooHigh
+	}
+	return nil
+}
+
+// BlockGasLimit returns the limit of the gas used by the transactions of the block enforced since Protea HF. It is the
+// smaller one of the PBFT block gas limit and of the gas limit of the block, zero means there is no limit
+func (self *Rules) BlockGasLimit(blk *BlockInfo) uint64 {
+	if !self.IsProtea {
+		return 0
+	}
+	limit := self.PbftGasLimit
+	if blk.GasLimit != 0 && (limit == 0 || blk.GasLimit < limit) {
+		limit = blk.GasLimit
+	}
+	return limit
+}
+
+// effectiveGasPrice returns the gas price paid by the sender and the tip, which is the part of it
+// going to the validators. Since Lantana HF the rest of the gas price (block base fee) is burned (EIP-1559)
+func (self *EVM) effectiveGasPrice() (gas_price, tip *big.Int, err error) {
+	if !self.rules.IsLantana {
+		return self.trx.GasPrice, self.trx.GasPrice, nil
+	}
+	fee_cap, tip_cap := self.trx.GasPrice, self.trx.GasPrice
+	if self.trx.MaxFeePerGas != nil {
+		fee_cap, tip_cap = self.trx.MaxFeePerGas, bigutil.ZeroIfNIL(self.trx.MaxPriorityFeePerGas)
+		if tip_cap.Cmp(fee_cap) > 0 {
+			return nil, nil, ErrTipAboveFeeCap
+		}
+	}
+	// Zero price calls are allowed in dry runs and for system transactions
+	if (self.vmConfig.NoBaseFee || self.trx.From == common.ZeroAddress) && fee_cap.Sign() == 0 && tip_cap.Sign() == 0 {
+		return fee_cap, tip_cap, nil
+	}
+	base_fee := bigutil.ZeroIfNIL(self.block.BaseFee)
+	if fee_cap.Cmp(base_fee) < 0 {
+		return nil, nil, ErrFeeCapTooLow
+	}
+	if tip = bigutil.Sub(fee_cap, base_fee); tip.Cmp(tip_cap) > 0 {
+		tip = tip_cap
+	}
+	return bigutil.Add(base_fee, tip), tip, nil
+}
+
 // create_1 creates a new contract using code as deployment code.
 func (self *EVM) create_1(caller StateAccount, code []byte, gas uint64, value *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	contractAddr = crypto.CreateAddress(caller.Address(), caller.GetNonce())
-	ret, leftOverGas, err = self.create(caller, CodeAndHash{Code: code}, gas, value, &contractAddr)
+	ret, leftOverGas, err = self.create(CREATE, caller, CodeAndHash{Code: code}, gas, value, &contractAddr)
 	return
 }
 
@@ -279,13 +419,13 @@ func (self *EVM) create_1(caller StateAccount, code []byte, gas uint64, value *b
 func (self *EVM) create_2(caller StateAccount, code []byte, gas uint64, endowment *big.Int, salt *big.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
 	codeAndHash := CodeAndHash{code, keccak256.Hash(code)}
 	contractAddr = crypto.CreateAddress2(caller.Address(), self.bigconv.ToHash(salt), codeAndHash.CodeHash[:])
-	ret, leftOverGas, err = self.create(caller, codeAndHash, gas, endowment, &contractAddr)
+	ret, leftOverGas, err = self.create(CREATE2, caller, codeAndHash, gas, endowment, &contractAddr)
 	return
 }
 
-// create creates a new contract using code as deployment code.
+// create creates a new contract using code as deployment code, typ is the opcode reported to the tracer.
 func (self *EVM) create(
-	caller StateAccount, code CodeAndHash, gas uint64, value *big.Int, address *common.Address) (
+	typ OpCode, caller StateAccount, code CodeAndHash, gas uint64, value *big.Int, address *common.Address) (
 	ret []byte, gas_left uint64, err error) {
 	// Depth check execution. Fail if we're trying to execute above the
 	// limit.
@@ -297,6 +437,11 @@ func (self *EVM) create(
 	}
 	// TODO This should go after the state snapshot, but this is how it works in ETH
 	caller.IncrementNonce()
+	// We add this to the access list _before_ taking a snapshot. Even if the creation fails,
+	// the access-list change should not be rolled back
+	if self.rules.IsBerberis {
+		self.state.AddAddressToAccessList(address)
+	}
 	new_acc := self.state.GetAccount(address)
 	// Ensure there's no existing contract already at the designated address
 	if new_acc.GetNonce().Sign() != 0 || new_acc.GetCodeSize() != 0 {
@@ -305,6 +450,9 @@ func (self *EVM) create(
 	}
 	// create a new account on the state
 	snapshot := self.state.Snapshot()
+	if self.rules.IsSalvia {
+		self.state.MarkContractCreated(address)
+	}
 	new_acc.IncrementNonce()
 	self.transfer(caller, new_acc, value)
 	// initialise a new contract and set the code that is to be used by the
@@ -315,7 +463,7 @@ func (self *EVM) create(
 		if self.depth == 0 {
 			self.vmConfig.Tracer.CaptureStart(self, caller.Address(), address, false /* precompile */, true /* create */, code.Code, gas, value, nil)
 		} else {
-			self.vmConfig.Tracer.CaptureEnter(CREATE, caller.Address(), address, false /* precompile */, true /* create */, code.Code, gas, value, nil)
+			self.vmConfig.Tracer.CaptureEnter(typ, caller.Address(), address, false /* precompile */, true /* create */, code.Code, gas, value, nil)
 		}
 	}
 	start := time.Now()
@@ -405,7 +553,7 @@ func (self *EVM) call(typ OpCode, caller ContractRef, callee StateAccount, input
 	gas_copy := gas
 	gas_left = gas
 	precompiled := self.precompiles.Get(callee.Address())
-	code := callee.GetCode()
+	code, code_hash := self.resolveCode(callee)
 	start := time.Now()
 
 	if self.vmConfig.Debug {
@@ -444,7 +592,7 @@ func (self *EVM) call(typ OpCode, caller ContractRef, callee StateAccount, input
 			err = ErrOutOfGas
 		}
 	} else if len(code) != 0 {
-		contract := NewContract(frame, CodeAndHash{code, callee.GetCodeHash()})
+		contract := NewContract(frame, CodeAndHash{code, code_hash})
 		ret, err = self.run(&contract, read_only)
 		gas_left = contract.Gas
 		gas_copy = contract.Gas
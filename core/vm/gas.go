@@ -22,7 +22,7 @@ import (
 )
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func IntrinsicGas(data []byte, contractCreation bool) (uint64, error) {
+func IntrinsicGas(data []byte, accessList AccessList, authList []SetCodeAuthorization, contractCreation, isEIP3860 bool) (uint64, error) {
 	// Set the starting gas for the raw transaction
 	var gas uint64
 	if contractCreation {
@@ -50,7 +50,20 @@ func IntrinsicGas(data []byte, contractCreation bool) (uint64, error) {
 			return 0, ErrOutOfGas
 		}
 		gas += z * TxDataZeroGas
+
+		if contractCreation && isEIP3860 {
+			words := toWordSize(uint64(len(data)))
+			if (math.MaxUint64-gas)/InitCodeWordGas < words {
+				return 0, ErrOutOfGas
+			}
+			gas += words * InitCodeWordGas
+		}
 	}
+	if accessList != nil {
+		gas += uint64(len(accessList)) * TxAccessListAddressGas
+		gas += uint64(accessList.StorageKeys()) * TxAccessListStorageKeyGas
+	}
+	gas += uint64(len(authList)) * TxAuthTupleGas
 	return gas, nil
 }
 
@@ -350,6 +363,35 @@ func gasCreate(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySi
 	return gas, nil
 }
 
+// gasCreateEIP3860 and gasCreate2EIP3860 additionally limit the initcode size and charge for its words (EIP-3860)
+func gasCreateEIP3860(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	gas, err := gasCreate(evm, contract, stack, mem, memorySize)
+	if err != nil {
+		return 0, err
+	}
+	return addInitCodeGas(gas, stack.Back(2))
+}
+
+func gasCreate2EIP3860(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	gas, err := gasCreate2(evm, contract, stack, mem, memorySize)
+	if err != nil {
+		return 0, err
+	}
+	return addInitCodeGas(gas, stack.Back(2))
+}
+
+func addInitCodeGas(gas uint64, size_u256 *uint256.Int) (uint64, error) {
+	size, overflow := size_u256.Uint64WithOverflow()
+	if overflow || size > MaxInitCodeSize {
+		return 0, ErrMaxInitCodeSizeExceeded
+	}
+	// size is limited, so the word gas can't overflow
+	if gas, overflow = math.SafeAdd(gas, toWordSize(size)*InitCodeWordGas); overflow {
+		return 0, errGasUintOverflow
+	}
+	return gas, nil
+}
+
 func gasCreate2(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
 	var overflow bool
 	gas, err := memoryGasCost(mem, memorySize)
@@ -52,7 +52,24 @@ var (
 		Suicide:     5000,
 		ExpByte:     50,
 
-		CreateBySuicide: 25000,
+		CreateBySuicide:     25000,
 		WarmStorageReadCost: 100,
 	}
+
+	// GasTableBerberis contains the gas prices for the Berberis phase.
+	// Account and storage accesses are charged the warm price here, the
+	// cold access surcharge is added dynamically (EIP-2929).
+	GasTableBerberis = GasTable{
+		ExtcodeSize: WarmStorageReadCostEIP2929,
+		ExtcodeCopy: WarmStorageReadCostEIP2929,
+		ExtcodeHash: WarmStorageReadCostEIP2929,
+		Balance:     WarmStorageReadCostEIP2929,
+		SLoad:       WarmStorageReadCostEIP2929,
+		Calls:       WarmStorageReadCostEIP2929,
+		Suicide:     5000,
+		ExpByte:     50,
+
+		CreateBySuicide:     25000,
+		WarmStorageReadCost: WarmStorageReadCostEIP2929,
+	}
 )
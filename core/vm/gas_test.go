@@ -16,7 +16,15 @@
 
 package vm
 
-import "testing"
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+)
 
 func TestMemoryGasCost(t *testing.T) {
 	//size := uint64(math.MaxUint64 - 64)
@@ -34,3 +42,99 @@ func TestMemoryGasCost(t *testing.T) {
 		t.Error("expected error")
 	}
 }
+
+func TestIntrinsicGasAccessList(t *testing.T) {
+	access_list := AccessList{
+		{Address: common.Address{0x01}, StorageKeys: []common.Hash{{0x01}, {0x02}}},
+		{Address: common.Address{0x02}},
+	}
+	gas, err := IntrinsicGas([]byte{0x00, 0x01}, access_list, nil, false, false)
+	if err != nil {
+		t.Fatal("didn't expect error:", err)
+	}
+	if exp := TxGas + TxDataZeroGas + TxDataNonZeroGas + 2*TxAccessListAddressGas + 2*TxAccessListStorageKeyGas; gas != exp {
+		t.Errorf("Expected: %d, got %d", exp, gas)
+	}
+}
+
+func TestIntrinsicGasInitCode(t *testing.T) {
+	init_code := make([]byte, 33)
+	for _, is_eip3860 := range []bool{false, true} {
+		gas, err := IntrinsicGas(init_code, nil, nil, true, is_eip3860)
+		if err != nil {
+			t.Fatal("didn't expect error:", err)
+		}
+		exp := TxGasContractCreation + 33*TxDataZeroGas
+		if is_eip3860 {
+			exp += 2 * InitCodeWordGas
+		}
+		if gas != exp {
+			t.Errorf("EIP-3860 %v: expected %d, got %d", is_eip3860, exp, gas)
+		}
+	}
+	// calls are not charged for the words
+	if gas, _ := IntrinsicGas(init_code, nil, nil, false, true); gas != TxGas+33*TxDataZeroGas {
+		t.Errorf("call: got %d", gas)
+	}
+}
+
+func TestTransactionAccessListRLP(t *testing.T) {
+	to := common.Address{0x01}
+	trx := Transaction{GasPrice: big.NewInt(1), To: &to, Nonce: big.NewInt(2), Value: big.NewInt(3), Gas: 4, Input: []byte{5}}
+	legacy := struct {
+		From     common.Address
+		GasPrice *big.Int
+		To       *common.Address `rlp:"nil"`
+		Nonce    *big.Int
+		Value    *big.Int
+		Gas      uint64
+		Input    []byte
+	}{trx.From, trx.GasPrice, trx.To, trx.Nonce, trx.Value, trx.Gas, trx.Input}
+
+	// transaction without an access list has the same encoding as before EIP-2930
+	enc, legacy_enc := rlp.MustEncodeToBytes(&trx), rlp.MustEncodeToBytes(&legacy)
+	if !bytes.Equal(enc, legacy_enc) {
+		t.Fatalf("encoding mismatch: have %x, want %x", enc, legacy_enc)
+	}
+
+	trx.AccessList = AccessList{{Address: to, StorageKeys: []common.Hash{{0x02}}}}
+	var decoded Transaction
+	rlp.MustDecodeBytes(rlp.MustEncodeToBytes(&trx), &decoded)
+	if !reflect.DeepEqual(decoded.AccessList, trx.AccessList) {
+		t.Fatalf("access list mismatch: have %v, want %v", decoded.AccessList, trx.AccessList)
+	}
+}
+
+func TestEffectiveGasPrice(t *testing.T) {
+	for i, test := range []struct {
+		base_fee, gas_price, fee_cap, tip_cap int64
+		dynamic                               bool
+		exp_price, exp_tip                    int64
+		exp_err                               error
+	}{
+		{base_fee: 10, gas_price: 15, exp_price: 15, exp_tip: 5},
+		{base_fee: 10, gas_price: 9, exp_err: ErrFeeCapTooLow},
+		{base_fee: 10, dynamic: true, fee_cap: 20, tip_cap: 3, exp_price: 13, exp_tip: 3},
+		{base_fee: 10, dynamic: true, fee_cap: 12, tip_cap: 3, exp_price: 12, exp_tip: 2},
+		{base_fee: 10, dynamic: true, fee_cap: 9, tip_cap: 3, exp_err: ErrFeeCapTooLow},
+		{base_fee: 10, dynamic: true, fee_cap: 20, tip_cap: 21, exp_err: ErrTipAboveFeeCap},
+	} {
+		var evm EVM
+		evm.rules.IsLantana = true
+		evm.block.BaseFee = big.NewInt(test.base_fee)
+		evm.trx = &Transaction{From: common.Address{0x01}, GasPrice: big.NewInt(test.gas_price)}
+		if test.dynamic {
+			evm.trx.MaxFeePerGas, evm.trx.MaxPriorityFeePerGas = big.NewInt(test.fee_cap), big.NewInt(test.tip_cap)
+		}
+		price, tip, err := evm.effectiveGasPrice()
+		if err != test.exp_err {
+			t.Fatalf("test %d: expected error %v, got %v", i, test.exp_err, err)
+		}
+		if err != nil {
+			continue
+		}
+		if price.Int64() != test.exp_price || tip.Int64() != test.exp_tip {
+			t.Errorf("test %d: expected price %d and tip %d, got %d and %d", i, test.exp_price, test.exp_tip, price, tip)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package vm
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
@@ -415,7 +416,7 @@ func opExtCodeHash(pc *uint64, evm *EVM, contract *Contract, memory *Memory, sta
 }
 
 func opGasprice(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	v, _ := uint256.FromBig(evm.trx.GasPrice)
+	v, _ := uint256.FromBig(evm.gas_price)
 	stack.push(v)
 	return nil, nil
 }
@@ -769,10 +770,35 @@ func opStop(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Sta
 func opSuicide(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	beneficiary := stack.pop()
 	addr := common.Address(beneficiary.Bytes20())
+	captureSuicide(evm, contract, &addr)
 	contract.Account().Suicide(&addr)
 	return nil, nil
 }
 
+func opSuicide6780(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	beneficiary := stack.pop()
+	addr := common.Address(beneficiary.Bytes20())
+	captureSuicide(evm, contract, &addr)
+	if evm.state.IsContractCreated(contract.Address()) {
+		contract.Account().Suicide(&addr)
+		return nil, nil
+	}
+	// The account is kept, the balance is not burned if the beneficiary is the contract itself
+	balance := new(big.Int).Set(contract.Account().GetBalance())
+	evm.transfer(contract.Account(), evm.state.GetAccount(&addr), balance)
+	return nil, nil
+}
+
+// captureSuicide reports SELFDESTRUCT to the tracer as a frame transferring the balance to the beneficiary
+func captureSuicide(evm *EVM, contract *Contract, beneficiary *common.Address) {
+	if !evm.vmConfig.Debug {
+		return
+	}
+	balance := contract.Account().GetBalance()
+	evm.vmConfig.Tracer.CaptureEnter(SELFDESTRUCT, contract.Address(), beneficiary, false /* precompile */, false /* create */, nil, 0, balance, nil)
+	evm.vmConfig.Tracer.CaptureExit(nil, 0, 0, nil)
+}
+
 // following functions are used by the instruction jump  table
 
 // make log instruction function
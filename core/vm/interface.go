@@ -35,6 +35,15 @@ type State interface {
 	// EIP1153
 	GetTransientState(addr *common.Address, key common.Hash) common.Hash
 	SetTransientState(addr *common.Address, key, value common.Hash)
+	// EIP2929
+	PrepareAccessList()
+	AddressInAccessList(addr *common.Address) bool
+	SlotInAccessList(addr *common.Address, slot *common.Hash) (addressOk bool, slotOk bool)
+	AddAddressToAccessList(addr *common.Address)
+	AddSlotToAccessList(addr *common.Address, slot *common.Hash)
+	// EIP6780
+	MarkContractCreated(addr *common.Address)
+	IsContractCreated(addr *common.Address) bool
 }
 
 type StateAccount interface {
@@ -53,6 +62,7 @@ type StateAccount interface {
 	// Empty returns whether the given account is empty. Empty
 	// is defined according to EIP161 (balance = nonce = code = 0).
 	IsEIP161Empty() bool
+	// SetCode replaces the code, empty code clears it
 	SetCode([]byte)
 	AddBalance(*big.Int)
 	SubBalance(*big.Int)
@@ -52,10 +52,51 @@ type operation struct {
 }
 
 var (
+	tiliaInstructionSet        = newTiliaInstructionSet()
+	salviaInstructionSet       = newSalviaInstructionSet()
+	rosaInstructionSet         = newRosaInstructionSet()
+	lantanaInstructionSet      = newLantanaInstructionSet()
+	berberisInstructionSet     = newBerberisInstructionSet()
 	ficusInstructionSet        = newFicusInstructionSet()
 	californicumInstructionSet = newCalifornicumInstructionSet()
 )
 
+// newTiliaInstructionSet returns the instructions with EIP-7702 delegated code access costs
+func newTiliaInstructionSet() InstructionSet {
+	instructionSet := newSalviaInstructionSet()
+	enable7702(&instructionSet) // EIP-7702 (set EOA account code)
+	return instructionSet
+}
+
+// newSalviaInstructionSet returns the instructions with EIP-6780 SELFDESTRUCT semantics
+func newSalviaInstructionSet() InstructionSet {
+	instructionSet := newRosaInstructionSet()
+	enable6780(&instructionSet) // EIP-6780 (SELFDESTRUCT only in same transaction)
+	return instructionSet
+}
+
+// newRosaInstructionSet returns the instructions with EIP-3529 reduced refunds and EIP-3860 initcode metering
+func newRosaInstructionSet() InstructionSet {
+	instructionSet := newLantanaInstructionSet()
+	enable3529(&instructionSet) // EIP-3529 (reduction in refunds)
+	enable3860(&instructionSet) // EIP-3860 (limit and meter initcode)
+	return instructionSet
+}
+
+// newLantanaInstructionSet returns the instructions of the fee market (EIP-1559) phase
+func newLantanaInstructionSet() InstructionSet {
+	instructionSet := newBerberisInstructionSet()
+	enable3198(&instructionSet) // EIP-3198 (BASEFEE opcode)
+	return instructionSet
+}
+
+// newBerberisInstructionSet returns the instructions with EIP-2929 state access gas costs
+func newBerberisInstructionSet() InstructionSet {
+	instructionSet := newFicusInstructionSet()
+	enable2929(&instructionSet) // EIP-2929 (gas cost increases for state access opcodes)
+	return instructionSet
+}
+
 // Example of new instruction after HF
 func newFicusInstructionSet() InstructionSet {
 	instructionSet := newCalifornicumInstructionSet()
@@ -99,11 +99,13 @@ func (s *StructLog) ErrorString() string {
 // Note that reference types are actual VM data structures; make copies
 // if you need to retain them beyond the current call.
 type Tracer interface {
+	CaptureTxStart(env *EVM, trx *Transaction) error
 	CaptureStart(env *EVM, from *common.Address, to *common.Address, precompile bool, create bool, input []byte, gas uint64, value *big.Int, code []byte) error
 	CaptureEnter(op OpCode, from *common.Address, to *common.Address, precompile bool, create bool, input []byte, gas uint64, value *big.Int, code []byte) error
 	CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth uint16, err error) error
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error
 	CaptureExit(output []byte, gasUsed uint64, t time.Duration, err error) error
+	CaptureTxEnd(result *ExecutionResult) error
 }
 
 // StructLogger is an EVM state logger and implements Tracer.
@@ -131,6 +133,14 @@ func NewStructLogger(cfg *LogConfig) *StructLogger {
 	return logger
 }
 
+func (l *StructLogger) CaptureTxStart(env *EVM, trx *Transaction) error {
+	return nil
+}
+
+func (l *StructLogger) CaptureTxEnd(result *ExecutionResult) error {
+	return nil
+}
+
 func (l *StructLogger) CaptureStart(env *EVM, from *common.Address, to *common.Address, precompile bool, create bool, input []byte, gas uint64, value *big.Int, code []byte) error {
 	return nil
 }
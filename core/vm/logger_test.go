@@ -61,6 +61,15 @@ func (*dummyStatedb) GetTransientState(addr *common.Address, key common.Hash) co
 	return common.Hash{}
 }
 func (*dummyStatedb) SetTransientState(addr *common.Address, key, value common.Hash) {}
+func (*dummyStatedb) PrepareAccessList()                                             {}
+func (*dummyStatedb) AddressInAccessList(addr *common.Address) bool                  { return true }
+func (*dummyStatedb) SlotInAccessList(addr *common.Address, slot *common.Hash) (bool, bool) {
+	return true, true
+}
+func (*dummyStatedb) AddAddressToAccessList(addr *common.Address)                 {}
+func (*dummyStatedb) AddSlotToAccessList(addr *common.Address, slot *common.Hash) {}
+func (*dummyStatedb) MarkContractCreated(addr *common.Address)                    {}
+func (*dummyStatedb) IsContractCreated(addr *common.Address) bool                 { return false }
 
 func TestStoreCapture(t *testing.T) {
 	var (
@@ -0,0 +1,23 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// NativeTracer is a Tracer producing the same JSON result as the geth native tracer of the same name
+type NativeTracer interface {
+	Tracer
+	GetResult() (json.RawMessage, error)
+}
+
+// NewNativeTracer creates the native tracer by its name, cfg is the JSON tracer config
+func NewNativeTracer(name string, cfg json.RawMessage) (NativeTracer, error) {
+	switch name {
+	case CallTracerName:
+		return NewCallTracer(cfg)
+	case PrestateTracerName:
+		return NewPrestateTracer(cfg)
+	}
+	return nil, fmt.Errorf("tracer %q not found", name)
+}
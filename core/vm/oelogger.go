@@ -23,6 +23,10 @@ type TracingConfig struct {
 	VmTrace   bool
 	Trace     bool
 	StateDiff bool
+	// Name of the native tracer, e.g. callTracer or prestateTracer. OeTracer is used if empty
+	Tracer string `rlp:"optional"`
+	// JSON config of the native tracer
+	TracerConfig []byte `rlp:"optional"`
 }
 
 type ParityTrace struct {
@@ -310,6 +314,14 @@ func (ot *OeTracer) captureStartOrEnter(deep bool, typ OpCode, from *common.Addr
 	ot.traceStack = append(ot.traceStack, trace)
 }
 
+func (ot *OeTracer) CaptureTxStart(env *EVM, trx *Transaction) error {
+	return nil
+}
+
+func (ot *OeTracer) CaptureTxEnd(result *ExecutionResult) error {
+	return nil
+}
+
 func (ot *OeTracer) CaptureStart(env *EVM, from *common.Address, to *common.Address, precompile bool, create bool, input []byte, gas uint64, value *big.Int, code []byte) error {
 	ot.captureStartOrEnter(false /* deep */, CALL, from, to, precompile, create, input, gas, value, code)
 	return nil
@@ -430,7 +442,7 @@ func (ot *OeTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint6
 				ADD, EXP, CALLER, KECCAK256, SUB, ADDRESS, GAS, MUL, RETURNDATASIZE, NOT, SHR, SHL,
 				EXTCODESIZE, SLT, OR, NUMBER, PC, TIMESTAMP, BALANCE, SELFBALANCE, MULMOD, ADDMOD,
 				BLOCKHASH, BYTE, XOR, ORIGIN, CODESIZE, MOD, SIGNEXTEND, GASLIMIT, DIFFICULTY, SGT, GASPRICE,
-				MSIZE, EXTCODEHASH, SMOD, CHAINID, COINBASE:
+				MSIZE, EXTCODEHASH, SMOD, CHAINID, COINBASE, BASEFEE:
 				showStack = 1
 			}
 			for i := showStack - 1; i >= 0; i-- {
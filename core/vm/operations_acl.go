@@ -0,0 +1,202 @@
+package vm
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/common/math"
+	"github.com/holiman/uint256"
+)
+
+// makeGasSStoreFuncEIP2929 creates the SSTORE gas function according to EIP-2200 metering
+// combined with the EIP-2929 cold/warm slot access costs. clearingRefund is the amount
+// of gas refunded for clearing an originally existing storage slot.
+func makeGasSStoreFuncEIP2929(clearingRefund uint64) gasFunc {
+	return func(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+		// If we fail the minimum gas availability invariant, fail (0)
+		if contract.Gas <= SstoreSentryGasEIP2200 {
+			return 0, ErrSStoreSentry
+		}
+		var (
+			y, x    = stack.Back(1), stack.Back(0)
+			y_big   = y.ToBig()
+			x_big   = x.ToBig()
+			slot    = common.Hash(x.Bytes32())
+			current = contract.Account().GetState(x_big)
+			cost    = uint64(0)
+		)
+		// Check slot presence in the access list
+		if _, slot_present := evm.state.SlotInAccessList(contract.Address(), &slot); !slot_present {
+			cost = ColdSloadCostEIP2929
+			// If the caller cannot afford the cost, this change will be rolled back
+			evm.state.AddSlotToAccessList(contract.Address(), &slot)
+		}
+		if current.Cmp(y_big) == 0 { // noop (1)
+			return cost + WarmStorageReadCostEIP2929, nil
+		}
+		original := contract.Account().GetCommittedState(x_big)
+		if original.Cmp(current) == 0 {
+			if original.Sign() == 0 { // create slot (2.1.1)
+				return cost + SstoreSetGasEIP2200, nil
+			}
+			if y.Sign() == 0 { // delete slot (2.1.2b)
+				evm.state.AddRefund(clearingRefund)
+			}
+			return cost + (SstoreResetGasEIP2200 - ColdSloadCostEIP2929), nil // write existing slot (2.1.2)
+		}
+		if original.Sign() != 0 {
+			if current.Sign() == 0 { // recreate slot (2.2.1.1)
+				evm.state.SubRefund(clearingRefund)
+			} else if y.Sign() == 0 { // delete slot (2.2.1.2)
+				evm.state.AddRefund(clearingRefund)
+			}
+		}
+		if original.Cmp(y_big) == 0 {
+			if original.Sign() == 0 { // reset to original inexistent slot (2.2.2.1)
+				evm.state.AddRefund(SstoreSetGasEIP2200 - WarmStorageReadCostEIP2929)
+			} else { // reset to original existing slot (2.2.2.2)
+				evm.state.AddRefund((SstoreResetGasEIP2200 - ColdSloadCostEIP2929) - WarmStorageReadCostEIP2929)
+			}
+		}
+		return cost + WarmStorageReadCostEIP2929, nil // dirty update (2.2)
+	}
+}
+
+// gasSLoadEIP2929 calculates dynamic gas for SLOAD according to EIP-2929
+// For SLOAD, if the (address, storage_key) pair (where address is the address of the contract
+// whose storage is being read) is not yet in accessed_storage_keys,
+// charge 2100 gas and add the pair to accessed_storage_keys.
+// If the pair is already in accessed_storage_keys, charge 100 gas.
+func gasSLoadEIP2929(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	slot := common.Hash(stack.peek().Bytes32())
+	// Check slot presence in the access list
+	if _, slot_present := evm.state.SlotInAccessList(contract.Address(), &slot); !slot_present {
+		// If the caller cannot afford the cost, this change will be rolled back
+		// If he does afford it, we can skip checking the same thing later on, during execution
+		evm.state.AddSlotToAccessList(contract.Address(), &slot)
+		return ColdSloadCostEIP2929, nil
+	}
+	return WarmStorageReadCostEIP2929, nil
+}
+
+// coldAccountAccessSurcharge adds the address to the access list and returns the
+// extra gas to be charged on top of the warm access cost, if the address was cold.
+func coldAccountAccessSurcharge(evm *EVM, addr_as_u256 *uint256.Int) uint64 {
+	address := common.Address(addr_as_u256.Bytes20())
+	if evm.state.AddressInAccessList(&address) {
+		return 0
+	}
+	// If the caller cannot afford the cost, this change will be rolled back
+	evm.state.AddAddressToAccessList(&address)
+	return ColdAccountAccessCostEIP2929 - WarmStorageReadCostEIP2929
+}
+
+// gasExtCodeCopyEIP2929 implements extcodecopy according to EIP-2929
+// EIP spec:
+// > If the target is not in accessed_addresses,
+// > charge COLD_ACCOUNT_ACCESS_COST gas, and add the address to accessed_addresses.
+// > Otherwise, charge WARM_STORAGE_READ_COST gas.
+func gasExtCodeCopyEIP2929(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	// memory expansion first (dynamic part of pre-2929 implementation)
+	gas, err := gasExtCodeCopy(evm, contract, stack, mem, memorySize)
+	if err != nil {
+		return 0, err
+	}
+	var overflow bool
+	if gas, overflow = math.SafeAdd(gas, coldAccountAccessSurcharge(evm, stack.peek())); overflow {
+		return 0, errGasUintOverflow
+	}
+	return gas, nil
+}
+
+// gasBalanceEIP2929, gasExtCodeSizeEIP2929 and gasExtCodeHashEIP2929 charge the
+// cold account access surcharge according to EIP-2929 on top of the warm price
+// from the gas table.
+func gasBalanceEIP2929(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return evm.gas_table.Balance + coldAccountAccessSurcharge(evm, stack.peek()), nil
+}
+
+func gasExtCodeSizeEIP2929(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return evm.gas_table.ExtcodeSize + coldAccountAccessSurcharge(evm, stack.peek()), nil
+}
+
+func gasExtCodeHashEIP2929(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+	return evm.gas_table.ExtcodeHash + coldAccountAccessSurcharge(evm, stack.peek()), nil
+}
+
+// makeCallVariantGasCallEIP2929 wraps the gas function of a CALL variant and charges the
+// cold account access surcharge before the 63/64 call gas calculation takes place.
+// Since EIP-7702 the access to the account which the callee delegates to is charged as well.
+func makeCallVariantGasCallEIP2929(oldCalculator gasFunc, charge_delegation bool) gasFunc {
+	return func(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+		// The cold surcharge has to be deducted from the available gas before calculating the
+		// call gas, otherwise the callee could get more than 63/64 of what's left.
+		cold_cost := coldAccountAccessSurcharge(evm, stack.Back(1))
+		if charge_delegation {
+			if target, delegated := ParseDelegation(evm.get_account(stack.Back(1)).GetCode()); delegated {
+				target_cost := WarmStorageReadCostEIP2929
+				if !evm.state.AddressInAccessList(&target) {
+					evm.state.AddAddressToAccessList(&target)
+					target_cost = ColdAccountAccessCostEIP2929
+				}
+				cold_cost += target_cost
+			}
+		}
+		if cold_cost != 0 && !contract.UseGas(cold_cost) {
+			return 0, ErrOutOfGas
+		}
+		// Now call the old calculator, which takes into account
+		// - create new account
+		// - transfer value
+		// - memory expansion
+		// - 63/64ths rule
+		gas, err := oldCalculator(evm, contract, stack, mem, memorySize)
+		if cold_cost == 0 || err != nil {
+			return gas, err
+		}
+		// In case of a cold access, we temporarily add the cold charge back, and also
+		// add it to the returned gas. By adding it to the return, it will be charged
+		// outside of this function, as part of the dynamic gas, and that will make it
+		// also become correctly reported to tracers.
+		contract.Gas += cold_cost
+		var overflow bool
+		if gas, overflow = math.SafeAdd(gas, cold_cost); overflow {
+			return 0, errGasUintOverflow
+		}
+		return gas, nil
+	}
+}
+
+var (
+	gasCallEIP2929         = makeCallVariantGasCallEIP2929(gasCall, false)
+	gasDelegateCallEIP2929 = makeCallVariantGasCallEIP2929(gasDelegateCall, false)
+	gasStaticCallEIP2929   = makeCallVariantGasCallEIP2929(gasStaticCall, false)
+	gasCallCodeEIP2929     = makeCallVariantGasCallEIP2929(gasCallCode, false)
+	gasCallEIP7702         = makeCallVariantGasCallEIP2929(gasCall, true)
+	gasDelegateCallEIP7702 = makeCallVariantGasCallEIP2929(gasDelegateCall, true)
+	gasStaticCallEIP7702   = makeCallVariantGasCallEIP2929(gasStaticCall, true)
+	gasCallCodeEIP7702     = makeCallVariantGasCallEIP2929(gasCallCode, true)
+	gasSStoreEIP2929       = makeGasSStoreFuncEIP2929(SstoreClearsScheduleRefundEIP2200)
+	gasSStoreEIP3529       = makeGasSStoreFuncEIP2929(SstoreClearsScheduleRefundEIP3529)
+	gasSuicideEIP2929      = makeGasSuicideFuncEIP2929(true)
+	gasSuicideEIP3529      = makeGasSuicideFuncEIP2929(false)
+)
+
+// makeGasSuicideFuncEIP2929 creates the SELFDESTRUCT gas function which charges the cold account access cost for
+// the beneficiary on top of the regular SELFDESTRUCT cost (EIP-2929). There is no refund since EIP-3529
+func makeGasSuicideFuncEIP2929(refunds_enabled bool) gasFunc {
+	return func(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySize uint64) (uint64, error) {
+		gas := evm.gas_table.Suicide
+		address := common.Address(stack.peek().Bytes20())
+		if !evm.state.AddressInAccessList(&address) {
+			// If the caller cannot afford the cost, this change will be rolled back
+			evm.state.AddAddressToAccessList(&address)
+			gas += ColdAccountAccessCostEIP2929
+		}
+		if evm.state.GetAccount(&address).IsEIP161Empty() && contract.Account().GetBalance().Sign() != 0 {
+			gas += evm.gas_table.CreateBySuicide
+		}
+		if refunds_enabled && !contract.Account().HasSuicided() {
+			evm.state.AddRefund(SuicideRefundGas)
+		}
+		return gas, nil
+	}
+}
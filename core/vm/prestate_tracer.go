@@ -0,0 +1,245 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/common/hexutil"
+	"github.com/Taraxa-project/taraxa-evm/crypto"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+)
+
+const PrestateTracerName = "prestateTracer"
+
+type PrestateTracerConfig struct {
+	DiffMode       bool `json:"diffMode"`       // If true, this tracer will return state modifications
+	DisableCode    bool `json:"disableCode"`    // If true, this tracer will not return the contract code
+	DisableStorage bool `json:"disableStorage"` // If true, this tracer will not return the contract storage
+}
+
+// prestateAccount is the geth prestateTracer account, the fields are ordered as in its JSON
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+	empty   bool
+}
+
+func (self *prestateAccount) exists() bool {
+	return self.Nonce > 0 || len(self.Code) > 0 || len(self.Storage) > 0 || (self.Balance != nil && self.Balance.ToInt().Sign() != 0)
+}
+
+type prestateMap = map[common.Address]*prestateAccount
+
+// PrestateTracer is the native implementation of the geth prestateTracer, it collects the state of the accounts
+// touched by a transaction before the execution, and the changes of the state in the diff mode
+type PrestateTracer struct {
+	env     *EVM
+	pre     prestateMap
+	post    prestateMap
+	config  PrestateTracerConfig
+	created map[common.Address]bool
+	deleted map[common.Address]bool
+}
+
+func NewPrestateTracer(cfg json.RawMessage) (*PrestateTracer, error) {
+	self := &PrestateTracer{
+		pre:     prestateMap{},
+		post:    prestateMap{},
+		created: make(map[common.Address]bool),
+		deleted: make(map[common.Address]bool),
+	}
+	if len(cfg) != 0 {
+		if err := json.Unmarshal(cfg, &self.config); err != nil {
+			return nil, err
+		}
+	}
+	return self, nil
+}
+
+func (self *PrestateTracer) CaptureTxStart(env *EVM, trx *Transaction) error {
+	self.env = env
+	var to common.Address
+	if trx.To == nil {
+		to = crypto.CreateAddress(&trx.From, trx.Nonce)
+		self.created[to] = true
+	} else {
+		to = *trx.To
+	}
+	self.lookupAccount(trx.From)
+	self.lookupAccount(to)
+	self.lookupAccount(env.GetBlock().Author)
+	// The authorities are added to the prestate before the authorizations are applied
+	if env.GetRules().IsTilia {
+		for i := range trx.AuthorizationList {
+			if authority, err := trx.AuthorizationList[i].Authority(); err == nil {
+				self.lookupAccount(authority)
+			}
+		}
+	}
+	return nil
+}
+
+func (self *PrestateTracer) CaptureStart(env *EVM, from *common.Address, to *common.Address, precompile bool, create bool, input []byte, gas uint64, value *big.Int, code []byte) error {
+	return nil
+}
+
+func (self *PrestateTracer) CaptureEnter(typ OpCode, from *common.Address, to *common.Address, precompile bool, create bool, input []byte, gas uint64, value *big.Int, code []byte) error {
+	return nil
+}
+
+func (self *PrestateTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth uint16, err error) error {
+	if err != nil {
+		return nil
+	}
+	caller := *contract.Address()
+	switch op {
+	case SLOAD, SSTORE:
+		self.lookupStorage(caller, common.Hash(stack.Back(0).Bytes32()))
+	case EXTCODECOPY, EXTCODEHASH, EXTCODESIZE, BALANCE, SELFDESTRUCT:
+		self.lookupAccount(common.Address(stack.Back(0).Bytes20()))
+		if op == SELFDESTRUCT {
+			self.deleted[caller] = true
+		}
+	case DELEGATECALL, CALL, STATICCALL, CALLCODE:
+		self.lookupAccount(common.Address(stack.Back(1).Bytes20()))
+	case CREATE:
+		addr := crypto.CreateAddress(&caller, env.state.GetAccount(&caller).GetNonce())
+		self.lookupAccount(addr)
+		self.created[addr] = true
+	case CREATE2:
+		offset, size := stack.Back(1), stack.Back(2)
+		salt := common.Hash(stack.Back(3).Bytes32())
+		init_hash := keccak256.Hash(memory.GetPtr(int64(offset.Uint64()), int64(size.Uint64())))
+		addr := crypto.CreateAddress2(&caller, &salt, init_hash[:])
+		self.lookupAccount(addr)
+		self.created[addr] = true
+	}
+	return nil
+}
+
+func (self *PrestateTracer) CaptureExit(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	return nil
+}
+
+func (self *PrestateTracer) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	return nil
+}
+
+func (self *PrestateTracer) CaptureTxEnd(result *ExecutionResult) error {
+	if len(result.ConsensusErr) != 0 {
+		return nil
+	}
+	if self.config.DiffMode {
+		self.processDiffState()
+	}
+	// The prestate of the created contracts is empty unless they existed before the transaction
+	for addr := range self.created {
+		if acc := self.pre[addr]; acc != nil && acc.empty {
+			delete(self.pre, addr)
+		}
+	}
+	return nil
+}
+
+// GetResult returns the JSON of the prestate, or of the pre and post states in the diff mode
+func (self *PrestateTracer) GetResult() (json.RawMessage, error) {
+	if self.config.DiffMode {
+		return json.Marshal(struct {
+			Post prestateMap `json:"post"`
+			Pre  prestateMap `json:"pre"`
+		}{self.post, self.pre})
+	}
+	return json.Marshal(self.pre)
+}
+
+// processDiffState keeps only the modified accounts and storage slots in the prestate and collects their new values
+func (self *PrestateTracer) processDiffState() {
+	for addr, state := range self.pre {
+		// The deleted account's state is pruned from post but kept in pre
+		if self.deleted[addr] {
+			continue
+		}
+		modified := false
+		acc := self.env.state.GetAccount(&addr)
+		post := &prestateAccount{Storage: make(map[common.Hash]common.Hash)}
+		if balance := acc.GetBalance(); balance.Cmp(state.Balance.ToInt()) != 0 {
+			modified = true
+			post.Balance = (*hexutil.Big)(new(big.Int).Set(balance))
+		}
+		if nonce := acc.GetNonce().Uint64(); nonce != state.Nonce {
+			modified = true
+			post.Nonce = nonce
+		}
+		if !self.config.DisableCode {
+			if code := acc.GetCode(); !bytes.Equal(code, state.Code) {
+				modified = true
+				post.Code = common.CopyBytes(code)
+			}
+		}
+		for key, val := range state.Storage {
+			// Empty slots are not included
+			if val == (common.Hash{}) {
+				delete(state.Storage, key)
+			}
+			new_val := common.BytesToHash(acc.GetState(key.Big()).Bytes())
+			if val == new_val {
+				delete(state.Storage, key)
+				continue
+			}
+			modified = true
+			if new_val != (common.Hash{}) {
+				post.Storage[key] = new_val
+			}
+		}
+		if modified {
+			self.post[addr] = post
+		} else {
+			// The unmodified accounts are not included into the prestate
+			delete(self.pre, addr)
+		}
+	}
+}
+
+// lookupAccount fetches the account details and adds them to the prestate if it doesn't exist there yet
+func (self *PrestateTracer) lookupAccount(addr common.Address) {
+	if _, present := self.pre[addr]; present {
+		return
+	}
+	acc := self.env.state.GetAccount(&addr)
+	state := &prestateAccount{
+		Balance: (*hexutil.Big)(new(big.Int).Set(acc.GetBalance())),
+		Nonce:   acc.GetNonce().Uint64(),
+		Code:    common.CopyBytes(acc.GetCode()),
+	}
+	state.empty = !state.exists()
+	// The code is fetched anyway for the emptiness check
+	if self.config.DisableCode {
+		state.Code = nil
+	}
+	if !self.config.DisableStorage {
+		state.Storage = make(map[common.Hash]common.Hash)
+	}
+	self.pre[addr] = state
+}
+
+// lookupStorage fetches the storage slot and adds it to the prestate of the account if it doesn't exist there yet.
+// The account is expected to be already in the prestate, which holds for the executing contracts
+func (self *PrestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	if self.config.DisableStorage {
+		return
+	}
+	state := self.pre[addr]
+	if state == nil {
+		self.lookupAccount(addr)
+		state = self.pre[addr]
+	}
+	if _, present := state.Storage[key]; present {
+		return
+	}
+	state.Storage[key] = common.BytesToHash(self.env.state.GetAccount(&addr).GetState(key.Big()).Bytes())
+}
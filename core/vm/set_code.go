@@ -0,0 +1,127 @@
+package vm
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/crypto"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/bigutil"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+)
+
+// SetCodeAuthorization is the element type of a set code transaction authorization list (EIP-7702).
+// The authority signs keccak256(0x05 || rlp([ChainID, Address, Nonce])) to delegate its code to Address
+type SetCodeAuthorization struct {
+	ChainID *big.Int // zero means any chain
+	Address common.Address
+	Nonce   uint64
+	V       uint8 // y parity of the signature
+	R       *big.Int
+	S       *big.Int
+}
+
+// SetCodeAuthorizationMagic is prepended to the signed authorization payload
+const SetCodeAuthorizationMagic byte = 0x05
+
+// DelegationPrefix starts the code of an account which delegates its code to another account
+var DelegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// AddressToDelegation returns the delegation designator of the address
+func AddressToDelegation(addr *common.Address) []byte {
+	return append(common.CopyBytes(DelegationPrefix), addr[:]...)
+}
+
+// ParseDelegation returns the address which the code delegates to, if the code is a delegation designator
+func ParseDelegation(code []byte) (common.Address, bool) {
+	if len(code) != len(DelegationPrefix)+common.AddressLength || !bytes.HasPrefix(code, DelegationPrefix) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(code[len(DelegationPrefix):]), true
+}
+
+// SigHash returns the hash signed by the authority
+func (self *SetCodeAuthorization) SigHash() *common.Hash {
+	return keccak256.Hash([]byte{SetCodeAuthorizationMagic}, rlp.MustEncodeToBytes([]interface{}{self.ChainID, self.Address, self.Nonce}))
+}
+
+// Authority recovers the address of the signer of the authorization
+func (self *SetCodeAuthorization) Authority() (ret common.Address, err error) {
+	if self.R == nil || self.S == nil || !crypto.ValidateSignatureValues(self.V, self.R, self.S, true) {
+		return ret, ErrAuthorizationInvalidSignature
+	}
+	sig := make([]byte, 65)
+	self.R.FillBytes(sig[:32])
+	self.S.FillBytes(sig[32:64])
+	sig[64] = self.V
+	pub_key, err := crypto.Ecrecover(self.SigHash()[:], sig)
+	if err != nil {
+		return ret, ErrAuthorizationInvalidSignature
+	}
+	// the first byte of pubkey is bitcoin heritage
+	return common.BytesToAddress(keccak256.Hash(pub_key[1:])[12:]), nil
+}
+
+// applyAuthorizations sets the delegation designators of the authorities of the set code transaction. Invalid
+// authorizations are skipped, the gas charged for the authorizations of existing accounts is partially refunded
+func (self *EVM) applyAuthorizations(list []SetCodeAuthorization) {
+	for i := range list {
+		authority, err := self.validateAuthorization(&list[i])
+		if err != nil {
+			continue
+		}
+		if !authority.IsNIL() {
+			self.state.AddRefund(TxAuthTupleGas - TxAuthTupleBaseGas)
+		}
+		if list[i].Address == common.ZeroAddress {
+			authority.SetCode(nil)
+		} else {
+			authority.SetCode(AddressToDelegation(&list[i].Address))
+		}
+		authority.IncrementNonce()
+	}
+}
+
+// validateAuthorization returns the account of the authority if the authorization can be applied
+func (self *EVM) validateAuthorization(auth *SetCodeAuthorization) (StateAccount, error) {
+	if chain_id := bigutil.ZeroIfNIL(auth.ChainID); chain_id.Sign() != 0 && (!chain_id.IsUint64() || chain_id.Uint64() != self.chainConfig.ChainId) {
+		return nil, ErrAuthorizationWrongChainID
+	}
+	if auth.Nonce == math.MaxUint64 {
+		return nil, ErrAuthorizationNonceOverflow
+	}
+	address, err := auth.Authority()
+	if err != nil {
+		return nil, err
+	}
+	// The authority is warm even if the authorization is not applied
+	self.state.AddAddressToAccessList(&address)
+	authority := self.state.GetAccount(&address)
+	if _, delegated := ParseDelegation(authority.GetCode()); authority.GetCodeSize() != 0 && !delegated {
+		return nil, ErrAuthorizationDestinationHasCode
+	}
+	if nonce := authority.GetNonce(); !nonce.IsUint64() || nonce.Uint64() != auth.Nonce {
+		return nil, ErrAuthorizationNonceMismatch
+	}
+	return authority, nil
+}
+
+// resolveCode returns the code executed when the account is called. Since EIP-7702 the code of the account
+// which delegates to another account is the code of the latter, precompiles are considered to have no code
+func (self *EVM) resolveCode(acc StateAccount) ([]byte, *common.Hash) {
+	code := acc.GetCode()
+	if !self.rules.IsTilia {
+		return code, acc.GetCodeHash()
+	}
+	target, delegated := ParseDelegation(code)
+	if !delegated {
+		return code, acc.GetCodeHash()
+	}
+	if self.precompiles.Get(&target) != nil {
+		return nil, &crypto.EmptyBytesKeccak256
+	}
+	target_acc := self.state.GetAccount(&target)
+	return target_acc.GetCode(), target_acc.GetCodeHash()
+}
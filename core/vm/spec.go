@@ -0,0 +1,32 @@
+package vm
+
+// Spec is the part of the EVM which is defined by the hardforks. Unset parts are inherited from the previous hardforks
+type Spec struct {
+	InstructionSet *InstructionSet
+	GasTable       *GasTable
+	Precompiles    *Precompiles
+}
+
+// Override replaces the parts of self which are set in other
+func (self *Spec) Override(other *Spec) {
+	if other.InstructionSet != nil {
+		self.InstructionSet = other.InstructionSet
+	}
+	if other.GasTable != nil {
+		self.GasTable = other.GasTable
+	}
+	if other.Precompiles != nil {
+		self.Precompiles = other.Precompiles
+	}
+}
+
+var (
+	SpecCalifornicum = Spec{&californicumInstructionSet, &GasTableCalifornicum, &PrecompiledContractsCalifornicum}
+	SpecFicus        = Spec{&ficusInstructionSet, &GasTableCalifornicum, &PrecompiledContractsFicus}
+	SpecBerberis     = Spec{&berberisInstructionSet, &GasTableBerberis, &PrecompiledContractsFicus}
+	SpecLantana      = Spec{&lantanaInstructionSet, &GasTableBerberis, &PrecompiledContractsFicus}
+	SpecRosa         = Spec{InstructionSet: &rosaInstructionSet}
+	SpecSalvia       = Spec{InstructionSet: &salviaInstructionSet}
+	SpecTilia        = Spec{InstructionSet: &tiliaInstructionSet}
+	SpecViola        = Spec{Precompiles: &PrecompiledContractsViola}
+)
@@ -519,9 +519,34 @@ func makeStructWriter(typ reflect.Type) (writer, error) {
 	if err != nil {
 		return nil, err
 	}
+	if firstOptionalField(fields) == len(fields) {
+		writer := func(val reflect.Value, w *Encoder) error {
+			defer w.ListEnd(w.ListStart())
+			for _, f := range fields {
+				if err := f.info.writer(val.Field(f.index), w); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return writer, nil
+	}
+	// If there are any "optional" fields, the writer needs to perform additional
+	// checks to determine the output list length: trailing zero-valued optional
+	// fields are omitted.
 	writer := func(val reflect.Value, w *Encoder) error {
+		lastField := len(fields) - 1
+		for ; lastField >= 0; lastField-- {
+			f := fields[lastField]
+			if !f.optional {
+				break
+			}
+			if fv := val.Field(f.index); fv.Kind() == reflect.Slice && fv.Len() != 0 || fv.Kind() != reflect.Slice && !fv.IsZero() {
+				break
+			}
+		}
 		defer w.ListEnd(w.ListStart())
-		for _, f := range fields {
+		for _, f := range fields[:lastField+1] {
 			if err := f.info.writer(val.Field(f.index), w); err != nil {
 				return err
 			}
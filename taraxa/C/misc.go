@@ -6,6 +6,8 @@ import (
 	"runtime"
 	"runtime/debug"
 
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/chain_config"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util/bin"
 
 	"github.com/Taraxa-project/taraxa-evm/core"
@@ -38,6 +40,18 @@ func taraxa_evm_mainnet_initial_balances() C.taraxa_evm_Bytes {
 	return go_bytes_to_c(bin.BytesView(core.MainnetAllocData))
 }
 
+//export taraxa_evm_genesis_alloc_from_json
+func taraxa_evm_genesis_alloc_from_json(
+	json_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	ret, err := chain_config.GenesisAllocFromJSON(c_bytes_to_go(json_enc))
+	util.PanicIfNotNil(err)
+	enc_rlp(&ret, cb)
+}
+
 //export taraxa_evm_traceback
 func taraxa_evm_traceback(cb C.taraxa_evm_BytesCallback) {
 	call_bytes_cb(debug.Stack(), cb)
@@ -6,6 +6,7 @@ package main
 import "C"
 import (
 	"math/big"
+	"os"
 	"sync"
 	"unsafe"
 
@@ -13,14 +14,18 @@ import (
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/rewards_stats"
 	"github.com/holiman/uint256"
 
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db_rocksdb"
 
 	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/core/types"
 	"github.com/Taraxa-project/taraxa-evm/core/vm"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_dry_runner"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_snapshot"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util/asserts"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/bigutil"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util/bin"
 )
 
@@ -49,6 +54,7 @@ func taraxa_evm_state_api_new(
 		OptsDB       state_db_rocksdb.Opts
 	}
 	dec_rlp(params_enc, &params)
+	util.PanicIfNotNil(params.ChainConfig.Validate())
 	self := new(state_API)
 	self.db.Init(params.OptsDB)
 	self.get_blk_hash_C = *(*C.taraxa_evm_GetBlockHash)(unsafe.Pointer(params.GetBlockHash))
@@ -99,10 +105,27 @@ func taraxa_evm_state_api_update_state_config(
 		ChainConfig chain_config.ChainConfig
 	}
 	dec_rlp(params_enc, &params)
+	util.PanicIfNotNil(params.ChainConfig.Validate())
 	self := state_API_instances[ptr]
 	self.UpdateConfig(&params.ChainConfig)
 }
 
+//export taraxa_evm_state_api_active_hardforks
+func taraxa_evm_state_api_active_hardforks(
+	ptr C.taraxa_evm_state_API_ptr,
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params struct {
+		BlkNum types.BlockNum
+	}
+	dec_rlp(params_enc, &params)
+	ret := state_API_instances[ptr].ActiveHardforks(params.BlkNum)
+	enc_rlp(&ret, cb)
+}
+
 //export taraxa_evm_state_api_get_account
 func taraxa_evm_state_api_get_account(
 	ptr C.taraxa_evm_state_API_ptr,
@@ -140,6 +163,70 @@ func taraxa_evm_state_api_get_account_storage(
 	})
 }
 
+//export taraxa_evm_state_api_get_proof
+func taraxa_evm_state_api_get_proof(
+	ptr C.taraxa_evm_state_API_ptr,
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params struct {
+		BlkNum types.BlockNum
+		Addr   common.Address
+		Keys   []common.Hash
+	}
+	dec_rlp(params_enc, &params)
+	ret, err := state_API_instances[ptr].GetProof(params.BlkNum, &params.Addr, params.Keys)
+	util.PanicIfNotNil(err)
+	enc_rlp(&ret, cb)
+}
+
+//export taraxa_evm_state_api_account_range
+func taraxa_evm_state_api_account_range(
+	ptr C.taraxa_evm_state_API_ptr,
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params struct {
+		BlkNum types.BlockNum
+		Start  common.Hash
+		Limit  uint64
+		// Addresses to return with the accounts, in addition to the stored preimages
+		Addresses []common.Address
+	}
+	dec_rlp(params_enc, &params)
+	ret, err := state_API_instances[ptr].AccountRange(
+		params.BlkNum, &params.Start, params.Limit, state_db.KnownAddresses(params.Addresses, &state_API_instances[ptr].db))
+	util.PanicIfNotNil(err)
+	enc_rlp(&ret, cb)
+}
+
+//export taraxa_evm_state_api_storage_range
+func taraxa_evm_state_api_storage_range(
+	ptr C.taraxa_evm_state_API_ptr,
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params struct {
+		BlkNum types.BlockNum
+		Addr   common.Address
+		Start  common.Hash
+		Limit  uint64
+		// Keys to return with the values, in addition to the stored preimages
+		Keys []common.Hash
+	}
+	dec_rlp(params_enc, &params)
+	ret, err := state_API_instances[ptr].StorageRange(
+		params.BlkNum, &params.Addr, &params.Start, params.Limit, state_db.KnownKeys(params.Keys, &state_API_instances[ptr].db))
+	util.PanicIfNotNil(err)
+	enc_rlp(&ret, cb)
+}
+
 //export taraxa_evm_state_api_get_code_by_address
 func taraxa_evm_state_api_get_code_by_address(
 	ptr C.taraxa_evm_state_API_ptr,
@@ -163,15 +250,56 @@ func taraxa_evm_state_api_dry_run_transaction(
 	params_enc C.taraxa_evm_Bytes,
 	cb C.taraxa_evm_BytesCallback,
 	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params struct {
+		BlkNum         types.BlockNum
+		Blk            vm.BlockInfo
+		Trx            vm.Transaction
+		StateOverrides state_dry_runner.StateOverrides  `rlp:"optional"`
+		BlockOverrides *state_dry_runner.BlockOverrides `rlp:"optional"`
+	}
+	dec_rlp(params_enc, &params)
+	ret := state_API_instances[ptr].DryRunTransaction(&vm.Block{params.BlkNum, params.Blk}, &params.Trx, params.StateOverrides, params.BlockOverrides)
+	enc_rlp(&ret, cb)
+}
+
+//export taraxa_evm_state_api_estimate_gas
+func taraxa_evm_state_api_estimate_gas(
+	ptr C.taraxa_evm_state_API_ptr,
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
 ) {
 	defer handle_err(cb_err)
 	var params struct {
 		BlkNum types.BlockNum
 		Blk    vm.BlockInfo
 		Trx    vm.Transaction
+		GasCap uint64
+	}
+	dec_rlp(params_enc, &params)
+	ret := state_API_instances[ptr].EstimateGas(&vm.Block{params.BlkNum, params.Blk}, &params.Trx, params.GasCap)
+	enc_rlp(&ret, cb)
+}
+
+//export taraxa_evm_state_api_simulate
+func taraxa_evm_state_api_simulate(
+	ptr C.taraxa_evm_state_API_ptr,
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params struct {
+		BlkNum         types.BlockNum
+		Blk            vm.BlockInfo
+		Blocks         []state_dry_runner.SimulatedBlock
+		StateOverrides state_dry_runner.StateOverrides
+		Opts           state_dry_runner.SimulationOpts
 	}
 	dec_rlp(params_enc, &params)
-	ret := state_API_instances[ptr].DryRunTransaction(&vm.Block{params.BlkNum, params.Blk}, &params.Trx)
+	ret := state_API_instances[ptr].Simulate(&vm.Block{params.BlkNum, params.Blk}, params.Blocks, params.StateOverrides, params.Opts)
 	enc_rlp(&ret, cb)
 }
 
@@ -221,10 +349,13 @@ func taraxa_evm_state_api_execute_transactions(
 		tx := &params.Txs[i]
 		txResult := st.ExecuteTransaction(tx)
 
-		// Contract distribution is disabled - just add fee to the block author balance
+		// Contract distribution is disabled - just add fee to the block author balance. After Magnolia HF the fees are
+		// distributed by the DPOS contract from ValidatorStats.FeesRewards, which since Lantana HF has to be computed from
+		// EffectiveTip as the base fee is burned by ExecuteTransaction
 		if st.BlockNumber() < st.GetChainConfig().Hardforks.MagnoliaHf.BlockNum {
 			txFee := new(uint256.Int).SetUint64(txResult.GasUsed)
-			g, _ := uint256.FromBig(tx.GasPrice)
+			// Since Lantana HF only the tip goes to the author, base fee is burned or sent to the base fee sink
+			g, _ := uint256.FromBig(bigutil.ZeroIfNIL(txResult.EffectiveTip))
 			txFee.Mul(txFee, g)
 			st.AddTxFeeToBalance(&params.Blk.Author, txFee)
 		}
@@ -282,6 +413,18 @@ func taraxa_evm_state_api_transition_state_commit(
 	state_API_instances[ptr].GetStateTransition().Commit()
 }
 
+//export taraxa_evm_state_api_commit_barrier
+func taraxa_evm_state_api_commit_barrier(
+	ptr C.taraxa_evm_state_API_ptr,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	ret, err := state_API_instances[ptr].CommitBarrier()
+	util.PanicIfNotNil(err)
+	enc_rlp(&ret, cb)
+}
+
 //export taraxa_evm_state_api_dpos_is_eligible
 func taraxa_evm_state_api_dpos_is_eligible(
 	ptr C.taraxa_evm_state_API_ptr,
@@ -383,6 +526,103 @@ func taraxa_evm_state_api_db_snapshot(
 	util.PanicIfNotNil(db.Snapshot(dir, log_size_for_flush))
 }
 
+//export taraxa_evm_state_api_export_snapshot
+func taraxa_evm_state_api_export_snapshot(
+	ptr C.taraxa_evm_state_API_ptr,
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params struct {
+		Path   string
+		BlkNum types.BlockNum
+		// Addresses of the accounts with storage which preimages are not stored
+		Addresses []common.Address
+	}
+	dec_rlp(params_enc, &params)
+	f, err := os.Create(params.Path)
+	util.PanicIfNotNil(err)
+	defer f.Close()
+	db := &state_API_instances[ptr].db
+	ret, err := state_snapshot.Export(f, db, params.BlkNum, state_db.KnownAddresses(params.Addresses, db))
+	util.PanicIfNotNil(err)
+	util.PanicIfNotNil(f.Sync())
+	enc_rlp(&ret, cb)
+}
+
+//export taraxa_evm_state_import_snapshot
+func taraxa_evm_state_import_snapshot(
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params struct {
+		Path   string
+		OptsDB state_db_rocksdb.Opts
+	}
+	dec_rlp(params_enc, &params)
+	f, err := os.Open(params.Path)
+	util.PanicIfNotNil(err)
+	defer f.Close()
+	db := new(state_db_rocksdb.DB).Init(params.OptsDB)
+	defer db.Close()
+	ret, err := state_snapshot.Import(f, db)
+	util.PanicIfNotNil(err)
+	enc_rlp(&ret, cb)
+}
+
+type fsck_params struct {
+	BlkNum            types.BlockNum
+	RepairLatestViews bool
+	// Addresses of the accounts which storage values are verified, in addition to the stored preimages
+	Addresses []common.Address
+}
+
+func (self *fsck_params) opts(db *state_db_rocksdb.DB) state_db_rocksdb.FsckOpts {
+	return state_db_rocksdb.FsckOpts{
+		ResolveAddress:    state_db.KnownAddresses(self.Addresses, db),
+		RepairLatestViews: self.RepairLatestViews,
+	}
+}
+
+//export taraxa_evm_state_api_fsck
+func taraxa_evm_state_api_fsck(
+	ptr C.taraxa_evm_state_API_ptr,
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params fsck_params
+	dec_rlp(params_enc, &params)
+	ret, err := state_API_instances[ptr].db.Fsck(params.BlkNum, params.opts(&state_API_instances[ptr].db))
+	util.PanicIfNotNil(err)
+	enc_rlp(&ret, cb)
+}
+
+// taraxa_evm_state_fsck checks the database which is not opened by a state API
+//
+//export taraxa_evm_state_fsck
+func taraxa_evm_state_fsck(
+	params_enc C.taraxa_evm_Bytes,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	var params struct {
+		Fsck   fsck_params
+		OptsDB state_db_rocksdb.Opts
+	}
+	dec_rlp(params_enc, &params)
+	db := new(state_db_rocksdb.DB).Init(params.OptsDB)
+	defer db.Close()
+	ret, err := db.Fsck(params.Fsck.BlkNum, params.Fsck.opts(db))
+	util.PanicIfNotNil(err)
+	enc_rlp(&ret, cb)
+}
+
 //export taraxa_evm_state_api_prune
 func taraxa_evm_state_api_prune(
 	ptr C.taraxa_evm_state_API_ptr,
@@ -395,7 +635,28 @@ func taraxa_evm_state_api_prune(
 		BlkNum          types.BlockNum
 	}
 	dec_rlp(params_enc, &params)
-	state_API_instances[ptr].db.Prune(params.StateRootToKeep, params.BlkNum)
+	util.PanicIfNotNil(state_API_instances[ptr].db.Prune(params.StateRootToKeep, params.BlkNum))
+}
+
+//export taraxa_evm_state_api_prune_status
+func taraxa_evm_state_api_prune_status(
+	ptr C.taraxa_evm_state_API_ptr,
+	cb C.taraxa_evm_BytesCallback,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	ret := state_API_instances[ptr].db.GetPruneStatus()
+	enc_rlp(&ret, cb)
+}
+
+//export taraxa_evm_state_api_revert_to
+func taraxa_evm_state_api_revert_to(
+	ptr C.taraxa_evm_state_API_ptr,
+	blk_n uint64,
+	cb_err C.taraxa_evm_BytesCallback,
+) {
+	defer handle_err(cb_err)
+	util.PanicIfNotNil(state_API_instances[ptr].RevertTo(blk_n))
 }
 
 //export taraxa_evm_state_api_validators_stakes
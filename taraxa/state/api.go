@@ -14,7 +14,6 @@ import (
 
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/rewards_stats"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
-	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db_rocksdb"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_dry_runner"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_evm"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_transition"
@@ -23,31 +22,54 @@ import (
 )
 
 type API struct {
-	rocksdb          *state_db_rocksdb.DB
-	db               state_db.DB
+	db               DB
 	state_transition state_transition.StateTransition
 	dry_runner       state_dry_runner.DryRunner
 	trace_runner     state_dry_runner.TraceRunner
 	dpos             *dpos.API
 	config           *chain_config.ChainConfig
+	get_block_hash   vm.GetHashFunc
+	opts             APIOpts
+}
+
+// DB is the storage of the state, see state_db_rocksdb and state_db_memory
+type DB interface {
+	state_db.DB
+	GetDPOSConfigChanges() map[uint64][]byte
+	SaveDPOSConfigChange(blk uint64, cfg []byte)
+	RevertTo(blk_n types.BlockNum) error
+	GetStateRoot(blk_n types.BlockNum) *common.Hash
 }
 
 type APIOpts struct {
 	// TODO have single "perm-gen size" config property to derive all preallocation sizes
 	ExpectedMaxTrxPerBlock        uint64
 	MainTrieFullNodeLevelsToCache byte
+	// Number of the accounts changed by a block starting from which the main trie is committed concurrently,
+	// 0 means the default
+	MainTrieParallelCommitThreshold uint32 `rlp:"optional"`
 }
 
-func (self *API) Init(db *state_db_rocksdb.DB, get_block_hash vm.GetHashFunc, chain_cfg *chain_config.ChainConfig, opts APIOpts) *API {
+const default_main_trie_parallel_commit_threshold = 256
+
+func (self *API) Init(db DB, get_block_hash vm.GetHashFunc, chain_cfg *chain_config.ChainConfig, opts APIOpts) *API {
 	self.db = db
-	self.rocksdb = db
 	self.config = chain_cfg
+	self.get_block_hash = get_block_hash
+	self.opts = opts
+	if self.opts.MainTrieParallelCommitThreshold == 0 {
+		self.opts.MainTrieParallelCommitThreshold = default_main_trie_parallel_commit_threshold
+	}
+	self.init()
+	return self
+}
 
+func (self *API) init() {
 	self.dpos = new(dpos.API).Init(*self.config)
-	config_changes := self.rocksdb.GetDPOSConfigChanges()
+	config_changes := self.db.GetDPOSConfigChanges()
 	if len(config_changes) == 0 {
 		bytes := rlp.MustEncodeToBytes(self.config.DPOS)
-		self.rocksdb.SaveDPOSConfigChange(0, bytes)
+		self.db.SaveDPOSConfigChange(0, bytes)
 		self.dpos.UpdateConfig(0, *self.config)
 	} else {
 		// Order mapping keys to apply changes in correct order
@@ -68,27 +90,27 @@ func (self *API) Init(db *state_db_rocksdb.DB, get_block_hash vm.GetHashFunc, ch
 
 	self.state_transition.Init(
 		self.db.GetLatestState(),
-		get_block_hash,
+		self.get_block_hash,
 		self.dpos,
 		self.DPOSDelayedReader,
 		self.SlashingReader,
 		self.config,
 		state_transition.Opts{
 			EVMState: state_evm.Opts{
-				NumTransactionsToBuffer: opts.ExpectedMaxTrxPerBlock,
+				NumTransactionsToBuffer: self.opts.ExpectedMaxTrxPerBlock,
 			},
 			Trie: state_transition.TrieSinkOpts{
 				MainTrie: trie.WriterOpts{
-					FullNodeLevelsToCache: opts.MainTrieFullNodeLevelsToCache,
+					FullNodeLevelsToCache:   self.opts.MainTrieFullNodeLevelsToCache,
+					ParallelCommitThreshold: self.opts.MainTrieParallelCommitThreshold,
 				},
 			},
 		})
 	reader := func(blk_n types.BlockNum) contract_storage.StorageReader {
 		return self.ReadBlock(blk_n)
 	}
-	self.dry_runner.Init(self.db, get_block_hash, self.dpos, reader, self.config)
-	self.trace_runner.Init(self.db, get_block_hash, self.dpos, reader, self.config)
-	return self
+	self.dry_runner.Init(self.db, self.get_block_hash, self.dpos, reader, self.config)
+	self.trace_runner.Init(self.db, self.get_block_hash, self.dpos, reader, self.config)
 }
 
 func (self *API) UpdateConfig(chain_cfg *chain_config.ChainConfig) {
@@ -98,11 +120,21 @@ func (self *API) UpdateConfig(chain_cfg *chain_config.ChainConfig) {
 	self.trace_runner.UpdateConfig(self.config)
 	config_update_block_num := self.state_transition.LastBlockNum + 1
 	self.dpos.UpdateConfig(config_update_block_num, *self.config)
-	self.rocksdb.SaveDPOSConfigChange(config_update_block_num, rlp.MustEncodeToBytes(self.config.DPOS))
+	self.db.SaveDPOSConfigChange(config_update_block_num, rlp.MustEncodeToBytes(self.config.DPOS))
 	// Is not updating DPOS contract config. Usually you cannot update its field without additional that processes it
 	// So it should be updated separately, for example in specific hardfork function
 }
 
+// RevertTo drops the state committed after blk_n. The pending block, if any, is dropped as well.
+// Blocks without the stored state root, e.g. the ones committed before the revert support, can't be reverted to
+func (self *API) RevertTo(blk_n types.BlockNum) error {
+	self.state_transition.Close()
+	err := self.db.RevertTo(blk_n)
+	self.state_transition = state_transition.StateTransition{}
+	self.init()
+	return err
+}
+
 func (self *API) Close() {
 	self.state_transition.Close()
 }
@@ -128,8 +160,26 @@ func (self *API) GetCommittedStateDescriptor() state_db.StateDescriptor {
 	return self.db.GetLatestState().GetCommittedDescriptor()
 }
 
-func (self *API) DryRunTransaction(blk *vm.Block, trx *vm.Transaction) vm.ExecutionResult {
-	return self.dry_runner.Apply(blk, trx)
+// CommitBarrier waits until the committed blocks are written to the db and returns the last written one
+func (self *API) CommitBarrier() (state_db.StateDescriptor, error) {
+	return self.db.GetLatestState().Barrier()
+}
+
+// ActiveHardforks returns the names of the hardforks which are active at the block
+func (self *API) ActiveHardforks(blk_n types.BlockNum) []string {
+	return self.config.Hardforks.ActiveHardforks(blk_n)
+}
+
+func (self *API) DryRunTransaction(blk *vm.Block, trx *vm.Transaction, state_overrides state_dry_runner.StateOverrides, block_overrides *state_dry_runner.BlockOverrides) vm.ExecutionResult {
+	return self.dry_runner.Apply(blk, trx, state_overrides, block_overrides)
+}
+
+func (self *API) EstimateGas(blk *vm.Block, trx *vm.Transaction, gas_cap uint64) state_dry_runner.GasEstimation {
+	return self.dry_runner.EstimateGas(blk, trx, gas_cap)
+}
+
+func (self *API) Simulate(blk *vm.Block, blocks []state_dry_runner.SimulatedBlock, state_overrides state_dry_runner.StateOverrides, opts state_dry_runner.SimulationOpts) state_dry_runner.SimulationResult {
+	return self.dry_runner.Simulate(blk, blocks, state_overrides, opts)
 }
 
 func (self *API) Trace(blk *vm.Block, state_trxs *[]vm.Transaction, trxs *[]vm.Transaction, conf *vm.TracingConfig) []byte {
@@ -140,6 +190,39 @@ func (self *API) ReadBlock(blk_n types.BlockNum) state_db.ExtendedReader {
 	return state_db.GetBlockStateReader(self.db, blk_n)
 }
 
+// GetProof makes the proof of the account and its storage keys in the state of blk_n. The blocks without the state root
+// fail as in AccountRange
+func (self *API) GetProof(blk_n types.BlockNum, addr *common.Address, keys []common.Hash) (ret state_db.AccountProof, err error) {
+	state_root := self.db.GetStateRoot(blk_n)
+	if state_root == nil {
+		return ret, state_db.ErrStateRootUnknown
+	}
+	return self.ReadBlock(blk_n).GetProof(state_root, addr, keys), nil
+}
+
+// AccountRange reads a page of the accounts of blk_n starting from the address hash start. The state root of blk_n is
+// needed, so the blocks committed before the state roots are stored fail with ErrStateRootUnknown, as in RevertTo
+func (self *API) AccountRange(
+	blk_n types.BlockNum, start *common.Hash, limit uint64, preimages state_db.AddressPreimages,
+) (ret state_db.AccountRange, err error) {
+	state_root := self.db.GetStateRoot(blk_n)
+	if state_root == nil {
+		return ret, state_db.ErrStateRootUnknown
+	}
+	return self.ReadBlock(blk_n).AccountRange(state_root, start, limit, preimages), nil
+}
+
+// StorageRange reads a page of the storage of the account in blk_n starting from the key hash start. The blocks
+// without the state root fail as in AccountRange, so an empty page always means that the account has no storage
+func (self *API) StorageRange(
+	blk_n types.BlockNum, addr *common.Address, start *common.Hash, limit uint64, preimages state_db.KeyPreimages,
+) (ret state_db.StorageRange, err error) {
+	if self.db.GetStateRoot(blk_n) == nil {
+		return ret, state_db.ErrStateRootUnknown
+	}
+	return self.ReadBlock(blk_n).StorageRange(addr, start, limit, preimages), nil
+}
+
 func (self *API) DPOSReader(blk_n types.BlockNum) dpos.Reader {
 	return self.dpos.NewReader(blk_n, func(blk_n types.BlockNum) contract_storage.StorageReader {
 		return self.ReadBlock(blk_n)
@@ -1,52 +1,14 @@
 package chain_config
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/core"
-	"github.com/Taraxa-project/taraxa-evm/core/types"
-	"github.com/Taraxa-project/taraxa-evm/core/vm"
 	"github.com/Taraxa-project/taraxa-evm/params"
 )
 
-type Redelegation struct {
-	Validator common.Address
-	Delegator common.Address
-	Amount    *big.Int
-}
-
-type MagnoliaHfConfig struct {
-	BlockNum uint64
-	JailTime uint64 // [number of blocks]
-}
-
-type AspenHfConfig struct {
-	BlockNumPartOne  uint64 // part 1 just starts to save minted tokens (rewards) in db
-	BlockNumPartTwo  uint64 // part 2 implements new dynamic yield curve
-	MaxSupply        *big.Int
-	GeneratedRewards *big.Int // Total number of generated rewards between block 0 and AspenHf BlockNum
-}
-
-type FicusHfConfig struct {
-	BlockNum              uint64
-	PillarBlocksInterval  uint64 // [number of blocks]
-	BridgeContractAddress common.Address
-}
-
-type CornusHfConfig struct {
-	BlockNum                uint64
-	DelegationLockingPeriod uint32 // [number of blocks]
-	DagGasLimit             uint64
-	PbftGasLimit            uint64
-}
-
-type SoleiroliaHfConfig struct {
-	BlockNum       uint64
-	TrxMinGasPrice uint64 // [wei]
-	TrxMaxGasLimit uint64
-}
-
 // Leaving it here for next HF
 // type BambooRedelegation struct {
 // 	Validator common.Address
@@ -68,55 +30,13 @@ type HardforksConfig struct {
 	FicusHf                      FicusHfConfig
 	CornusHf                     CornusHfConfig
 	SoleiroliaHf                 SoleiroliaHfConfig
-}
-
-func (c *HardforksConfig) IsOnFixClaimAllHardfork(block types.BlockNum) bool {
-	return block >= c.FixClaimAllBlockNum
-}
-
-func (c *HardforksConfig) IsOnPhalaenopsisHardfork(block types.BlockNum) bool {
-	return block >= c.PhalaenopsisHfBlockNum
-}
-
-func (c *HardforksConfig) IsOnMagnoliaHardfork(block types.BlockNum) bool {
-	return block >= c.MagnoliaHf.BlockNum
-}
-
-func (c *HardforksConfig) IsOnAspenHardforkPartOne(block types.BlockNum) bool {
-	return block >= c.AspenHf.BlockNumPartOne
-}
-
-func (c *HardforksConfig) IsOnAspenHardforkPartTwo(block types.BlockNum) bool {
-	return block >= c.AspenHf.BlockNumPartTwo
-}
-
-func (c *HardforksConfig) IsOnFicusHardfork(block types.BlockNum) bool {
-	return block >= c.FicusHf.BlockNum
-}
-
-func (c *HardforksConfig) IsOnCornusHardfork(block types.BlockNum) bool {
-	return block >= c.CornusHf.BlockNum
-}
-
-func (c *HardforksConfig) IsCornusHardfork(block types.BlockNum) bool {
-	return block == c.CornusHf.BlockNum
-}
-
-func isForked(fork_start, block_num types.BlockNum) bool {
-	if fork_start == types.BlockNumberNIL || block_num == types.BlockNumberNIL {
-		return false
-	}
-	return fork_start <= block_num
-}
-
-func (c *HardforksConfig) Rules(num types.BlockNum) vm.Rules {
-	return vm.Rules{
-		IsMagnolia:     isForked(c.MagnoliaHf.BlockNum, num),
-		IsAspenPartOne: isForked(c.AspenHf.BlockNumPartOne, num),
-		IsAspenPartTwo: isForked(c.AspenHf.BlockNumPartTwo, num),
-		IsFicus:        isForked(c.FicusHf.BlockNum, num),
-		IsCornus:       isForked(c.CornusHf.BlockNum, num),
-	}
+	BerberisHf                   BerberisHfConfig
+	LantanaHf                    LantanaHfConfig
+	RosaHf                       RosaHfConfig
+	SalviaHf                     SalviaHfConfig
+	TiliaHf                      TiliaHfConfig
+	ViolaHf                      ViolaHfConfig
+	ProteaHf                     ProteaHfConfig
 }
 
 type GenesisValidator struct {
@@ -150,6 +70,23 @@ type ChainConfig struct {
 	GenesisBalances core.BalanceMap
 	DPOS            DPOSConfig
 	Hardforks       HardforksConfig
+	// Accounts with code, nonce and storage, applied after GenesisBalances
+	GenesisAlloc GenesisAlloc `rlp:"optional"`
+}
+
+// The fees rewards of the validators are computed by the client since Magnolia HF, which precedes Lantana HF, and the
+// base fee can't be taken out of them here. A base fee sink would be paid the base fee on top of the validators
+var ErrBaseFeeSinkNotSupported = errors.New("base fee sink is not supported")
+
+// Validate checks the settings which the state transition doesn't support
+func (self *ChainConfig) Validate() error {
+	if err := self.Hardforks.validate(); err != nil {
+		return err
+	}
+	if self.Hardforks.LantanaHf.BaseFeeSink != common.ZeroAddress {
+		return ErrBaseFeeSinkNotSupported
+	}
+	return nil
 }
 
 func (self *ChainConfig) RewardsEnabled() bool {
@@ -161,6 +98,11 @@ func (self *ChainConfig) GenesisBalancesSum() *big.Int {
 	for _, balance := range self.GenesisBalances {
 		sum.Add(sum, balance)
 	}
+	for _, acc := range self.GenesisAlloc {
+		if acc.Balance != nil {
+			sum.Add(sum, acc.Balance)
+		}
+	}
 
 	return sum
 }
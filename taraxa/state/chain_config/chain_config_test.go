@@ -0,0 +1,39 @@
+package chain_config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+)
+
+func TestValidate(t *testing.T) {
+	var cfg ChainConfig
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Hardforks.LantanaHf.BaseFeeSink = common.Address{1}
+	if err := cfg.Validate(); err != ErrBaseFeeSinkNotSupported {
+		t.Fatalf("base fee sink: %v", err)
+	}
+	cfg.Hardforks.LantanaHf.BaseFeeSink = common.Address{}
+
+	// the hardforks can't be activated before the previous ones, including the disabled ones
+	cfg.Hardforks.RosaHf.BlockNum, cfg.Hardforks.LantanaHf.BlockNum = 10, 20
+	if err := cfg.Validate(); !errors.Is(err, ErrHardforksOrder) {
+		t.Fatalf("Rosa before Lantana: %v", err)
+	}
+	cfg.Hardforks.RosaHf.BlockNum = types.BlockNumberNIL
+	if err := cfg.Validate(); !errors.Is(err, ErrHardforksOrder) {
+		t.Fatalf("hardforks after the disabled Rosa: %v", err)
+	}
+	cfg.Hardforks.RosaHf.BlockNum = 20
+	for _, blk_n := range []*uint64{&cfg.Hardforks.SalviaHf.BlockNum, &cfg.Hardforks.TiliaHf.BlockNum, &cfg.Hardforks.ViolaHf.BlockNum} {
+		*blk_n = 30
+	}
+	cfg.Hardforks.ProteaHf.BlockNum = types.BlockNumberNIL
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,88 @@
+package chain_config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/common/hexutil"
+	"github.com/Taraxa-project/taraxa-evm/common/math"
+)
+
+// GenesisAccount is an account of the genesis state, in addition to GenesisBalances
+type GenesisAccount struct {
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+	Storage map[common.Hash]common.Hash
+}
+
+type GenesisAlloc = map[common.Address]GenesisAccount
+
+type genesis_account_json struct {
+	Balance *math.HexOrDecimal256         `json:"balance"`
+	Nonce   math.HexOrDecimal64           `json:"nonce"`
+	Code    hexutil.Bytes                 `json:"code"`
+	Storage map[storage_json]storage_json `json:"storage"`
+}
+
+// storage_json is a storage key or value of the geth alloc, which may be shorter than 32 bytes, e.g. "0x01"
+type storage_json common.Hash
+
+func (self *storage_json) UnmarshalText(text []byte) error {
+	text = bytes.TrimPrefix(bytes.TrimPrefix(text, []byte("0x")), []byte("0X"))
+	if len(text) > 2*common.HashLength {
+		return fmt.Errorf("too many hex characters in storage key/value %q", text)
+	}
+	if len(text)%2 != 0 {
+		text = append([]byte{'0'}, text...)
+	}
+	// padded on the left
+	if _, err := hex.Decode(self[common.HashLength-len(text)/2:], text); err != nil {
+		return fmt.Errorf("invalid hex storage key/value %q", text)
+	}
+	return nil
+}
+
+// GenesisAllocFromJSON decodes the alloc of the geth genesis. Either the whole genesis file or only its alloc
+// object is accepted
+func GenesisAllocFromJSON(data []byte) (ret GenesisAlloc, err error) {
+	var accounts map[string]json.RawMessage
+	if err = json.Unmarshal(data, &accounts); err != nil {
+		return
+	}
+	if alloc, is_genesis := accounts["alloc"]; is_genesis {
+		accounts = nil
+		if err = json.Unmarshal(alloc, &accounts); err != nil {
+			return
+		}
+	}
+	ret = make(GenesisAlloc, len(accounts))
+	for addr_hex, acc_json := range accounts {
+		// addresses of the geth alloc may be without the 0x prefix
+		addr_bytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(addr_hex, "0x"), "0X"))
+		if err != nil || len(addr_bytes) != common.AddressLength {
+			return nil, fmt.Errorf("invalid genesis alloc address %q", addr_hex)
+		}
+		var acc genesis_account_json
+		if err := json.Unmarshal(acc_json, &acc); err != nil {
+			return nil, fmt.Errorf("genesis alloc account %s: %w", addr_hex, err)
+		}
+		entry := GenesisAccount{Balance: big.NewInt(0), Nonce: uint64(acc.Nonce), Code: acc.Code}
+		if acc.Storage != nil {
+			entry.Storage = make(map[common.Hash]common.Hash, len(acc.Storage))
+			for k, v := range acc.Storage {
+				entry.Storage[common.Hash(k)] = common.Hash(v)
+			}
+		}
+		if acc.Balance != nil {
+			entry.Balance = (*big.Int)(acc.Balance)
+		}
+		ret[common.BytesToAddress(addr_bytes)] = entry
+	}
+	return
+}
@@ -0,0 +1,43 @@
+package chain_config
+
+import (
+	"math/big"
+
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+	dpos_sol "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/dpos/solidity"
+)
+
+type AspenHfConfig struct {
+	BlockNumPartOne  uint64 // part 1 just starts to save minted tokens (rewards) in db
+	BlockNumPartTwo  uint64 // part 2 implements new dynamic yield curve
+	MaxSupply        *big.Int
+	GeneratedRewards *big.Int // Total number of generated rewards between block 0 and AspenHf BlockNum
+}
+
+var aspen_part_one_hardfork = Hardfork{
+	Name:     "AspenPartOne",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.AspenHf.BlockNumPartOne },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsAspenPartOne = true },
+	Migrate: func(_ *HardforksConfig, state HardforkState) {
+		if dpos_addr := state.DPOSContractAddress(); dpos_addr != nil {
+			if acc := state.GetAccount(dpos_addr); acc.GetCodeSize() == 0 {
+				acc.SetCode(dpos_sol.AspenDposImplBytecode)
+			}
+		}
+	},
+}
+
+var aspen_part_two_hardfork = Hardfork{
+	Name:     "AspenPartTwo",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.AspenHf.BlockNumPartTwo },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsAspenPartTwo = true },
+}
+
+func (c *HardforksConfig) IsOnAspenHardforkPartOne(block types.BlockNum) bool {
+	return block >= c.AspenHf.BlockNumPartOne
+}
+
+func (c *HardforksConfig) IsOnAspenHardforkPartTwo(block types.BlockNum) bool {
+	return block >= c.AspenHf.BlockNumPartTwo
+}
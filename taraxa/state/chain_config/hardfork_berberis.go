@@ -0,0 +1,21 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+type BerberisHfConfig struct {
+	BlockNum uint64 // EIP-2929/2930 access lists and warm/cold state access gas costs
+}
+
+var berberis_hardfork = Hardfork{
+	Name:     "Berberis",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.BerberisHf.BlockNum },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsBerberis = true },
+	EVM:      vm.SpecBerberis,
+}
+
+func (c *HardforksConfig) IsOnBerberisHardfork(block types.BlockNum) bool {
+	return block >= c.BerberisHf.BlockNum
+}
@@ -0,0 +1,37 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+	dpos_sol "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/dpos/solidity"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_transition/op_stack"
+)
+
+type CornusHfConfig struct {
+	BlockNum                uint64
+	DelegationLockingPeriod uint32 // [number of blocks]
+	DagGasLimit             uint64 // enforced since ProteaHf
+	PbftGasLimit            uint64 // enforced since ProteaHf
+}
+
+var cornus_hardfork = Hardfork{
+	Name:     "Cornus",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.CornusHf.BlockNum },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsCornus = true },
+	Migrate: func(_ *HardforksConfig, state HardforkState) {
+		if dpos_addr := state.DPOSContractAddress(); dpos_addr != nil {
+			state.GetAccount(dpos_addr).SetCode(dpos_sol.CornusDposImplBytecode)
+		}
+		for addr, code := range op_stack.OpPrecompiles {
+			state.GetAccount(&addr).SetCode(code)
+		}
+	},
+}
+
+func (c *HardforksConfig) IsOnCornusHardfork(block types.BlockNum) bool {
+	return block >= c.CornusHf.BlockNum
+}
+
+func (c *HardforksConfig) IsCornusHardfork(block types.BlockNum) bool {
+	return block == c.CornusHf.BlockNum
+}
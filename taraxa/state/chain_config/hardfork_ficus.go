@@ -0,0 +1,24 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+type FicusHfConfig struct {
+	BlockNum              uint64
+	PillarBlocksInterval  uint64 // [number of blocks]
+	BridgeContractAddress common.Address
+}
+
+var ficus_hardfork = Hardfork{
+	Name:     "Ficus",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.FicusHf.BlockNum },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsFicus = true },
+	EVM:      vm.SpecFicus,
+}
+
+func (c *HardforksConfig) IsOnFicusHardfork(block types.BlockNum) bool {
+	return block >= c.FicusHf.BlockNum
+}
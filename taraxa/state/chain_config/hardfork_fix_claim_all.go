@@ -0,0 +1,12 @@
+package chain_config
+
+import "github.com/Taraxa-project/taraxa-evm/core/types"
+
+var fix_claim_all_hardfork = Hardfork{
+	Name:     "FixClaimAll",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.FixClaimAllBlockNum },
+}
+
+func (c *HardforksConfig) IsOnFixClaimAllHardfork(block types.BlockNum) bool {
+	return block >= c.FixClaimAllBlockNum
+}
@@ -0,0 +1,20 @@
+package chain_config
+
+import (
+	"math/big"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+)
+
+type Redelegation struct {
+	Validator common.Address
+	Delegator common.Address
+	Amount    *big.Int
+}
+
+// Redelegations are applied by the DPOS contract
+var fix_redelegate_hardfork = Hardfork{
+	Name:     "FixRedelegate",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.FixRedelegateBlockNum },
+}
@@ -0,0 +1,23 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+type LantanaHfConfig struct {
+	BlockNum    uint64         // EIP-1559 fee market
+	BaseFeeSink common.Address // receiver of the base fee part of transaction fees, zero address means the base fee is burned. Not supported yet, see ChainConfig.Validate
+}
+
+var lantana_hardfork = Hardfork{
+	Name:     "Lantana",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.LantanaHf.BlockNum },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsLantana = true },
+	EVM:      vm.SpecLantana,
+}
+
+func (c *HardforksConfig) IsOnLantanaHardfork(block types.BlockNum) bool {
+	return block >= c.LantanaHf.BlockNum
+}
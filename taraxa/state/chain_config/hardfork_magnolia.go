@@ -0,0 +1,22 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+type MagnoliaHfConfig struct {
+	BlockNum uint64
+	JailTime uint64 // [number of blocks]
+}
+
+// Slashing contract is registered since Magnolia HF
+var magnolia_hardfork = Hardfork{
+	Name:     "Magnolia",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.MagnoliaHf.BlockNum },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsMagnolia = true },
+}
+
+func (c *HardforksConfig) IsOnMagnoliaHardfork(block types.BlockNum) bool {
+	return block >= c.MagnoliaHf.BlockNum
+}
@@ -0,0 +1,12 @@
+package chain_config
+
+import "github.com/Taraxa-project/taraxa-evm/core/types"
+
+var phalaenopsis_hardfork = Hardfork{
+	Name:     "Phalaenopsis",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.PhalaenopsisHfBlockNum },
+}
+
+func (c *HardforksConfig) IsOnPhalaenopsisHardfork(block types.BlockNum) bool {
+	return block >= c.PhalaenopsisHfBlockNum
+}
@@ -0,0 +1,26 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+type ProteaHfConfig struct {
+	BlockNum uint64 // enforcement of the transaction limits of SoleiroliaHf and of the gas limits of CornusHf
+}
+
+var protea_hardfork = Hardfork{
+	Name:     "Protea",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.ProteaHf.BlockNum },
+	Rules: func(c *HardforksConfig, rules *vm.Rules) {
+		rules.IsProtea = true
+		rules.TrxMinGasPrice = c.SoleiroliaHf.TrxMinGasPrice
+		rules.TrxMaxGasLimit = c.SoleiroliaHf.TrxMaxGasLimit
+		rules.DagGasLimit = c.CornusHf.DagGasLimit
+		rules.PbftGasLimit = c.CornusHf.PbftGasLimit
+	},
+}
+
+func (c *HardforksConfig) IsOnProteaHardfork(block types.BlockNum) bool {
+	return block >= c.ProteaHf.BlockNum
+}
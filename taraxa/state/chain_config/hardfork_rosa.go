@@ -0,0 +1,21 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+type RosaHfConfig struct {
+	BlockNum uint64 // EIP-3529 reduced refunds and EIP-3860 initcode size limit and metering
+}
+
+var rosa_hardfork = Hardfork{
+	Name:     "Rosa",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.RosaHf.BlockNum },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsRosa = true },
+	EVM:      vm.SpecRosa,
+}
+
+func (c *HardforksConfig) IsOnRosaHardfork(block types.BlockNum) bool {
+	return block >= c.RosaHf.BlockNum
+}
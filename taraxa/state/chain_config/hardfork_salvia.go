@@ -0,0 +1,21 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+type SalviaHfConfig struct {
+	BlockNum uint64 // EIP-6780 SELFDESTRUCT deletes only the contracts created in the same transaction
+}
+
+var salvia_hardfork = Hardfork{
+	Name:     "Salvia",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.SalviaHf.BlockNum },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsSalvia = true },
+	EVM:      vm.SpecSalvia,
+}
+
+func (c *HardforksConfig) IsOnSalviaHardfork(block types.BlockNum) bool {
+	return block >= c.SalviaHf.BlockNum
+}
@@ -0,0 +1,18 @@
+package chain_config
+
+import "github.com/Taraxa-project/taraxa-evm/core/types"
+
+type SoleiroliaHfConfig struct {
+	BlockNum       uint64
+	TrxMinGasPrice uint64 // [wei], 0 means no limit. Enforced since ProteaHf
+	TrxMaxGasLimit uint64 // 0 means no limit. Enforced since ProteaHf
+}
+
+var soleirolia_hardfork = Hardfork{
+	Name:     "Soleirolia",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.SoleiroliaHf.BlockNum },
+}
+
+func (c *HardforksConfig) IsOnSoleiroliaHardfork(block types.BlockNum) bool {
+	return block >= c.SoleiroliaHf.BlockNum
+}
@@ -0,0 +1,21 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+type TiliaHfConfig struct {
+	BlockNum uint64 // EIP-7702 set code transactions delegating the code of EOAs
+}
+
+var tilia_hardfork = Hardfork{
+	Name:     "Tilia",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.TiliaHf.BlockNum },
+	Rules:    func(_ *HardforksConfig, rules *vm.Rules) { rules.IsTilia = true },
+	EVM:      vm.SpecTilia,
+}
+
+func (c *HardforksConfig) IsOnTiliaHardfork(block types.BlockNum) bool {
+	return block >= c.TiliaHf.BlockNum
+}
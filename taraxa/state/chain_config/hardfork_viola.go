@@ -0,0 +1,20 @@
+package chain_config
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+type ViolaHfConfig struct {
+	BlockNum uint64 // RIP-7212 P256VERIFY precompile
+}
+
+var viola_hardfork = Hardfork{
+	Name:     "Viola",
+	BlockNum: func(c *HardforksConfig) types.BlockNum { return c.ViolaHf.BlockNum },
+	EVM:      vm.SpecViola,
+}
+
+func (c *HardforksConfig) IsOnViolaHardfork(block types.BlockNum) bool {
+	return block >= c.ViolaHf.BlockNum
+}
@@ -0,0 +1,105 @@
+package chain_config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+// Hardfork is an entry of the hardfork registry, which Rules and ActiveHardforks are derived from. A new hardfork still
+// needs its config field in HardforksConfig and, if it changes the EVM, its flag in vm.Rules or its vm.Spec in core/vm
+type Hardfork struct {
+	Name string
+	// Block from which the hardfork is active, types.BlockNumberNIL means never
+	BlockNum func(*HardforksConfig) types.BlockNum
+	// Enables the hardfork in the EVM rules, called only if the hardfork is active. May be nil
+	Rules func(*HardforksConfig, *vm.Rules)
+	// EVM changes of the hardfork, the unset parts are inherited from the previous hardforks
+	EVM vm.Spec
+	// One-shot state changes applied at the beginning of the first block of the hardfork. May be nil
+	Migrate func(*HardforksConfig, HardforkState)
+}
+
+// HardforkState is the state which the hardfork migrations are applied to
+type HardforkState interface {
+	GetAccount(*common.Address) vm.StateAccount
+	// Address of the DPOS contract, nil if DPOS is disabled
+	DPOSContractAddress() *common.Address
+}
+
+// Hardforks in the order of their introduction, which is the order of their activation. EVM changes of the later ones
+// override the earlier ones
+var hardfork_registry = []*Hardfork{
+	&fix_redelegate_hardfork,
+	&magnolia_hardfork,
+	&phalaenopsis_hardfork,
+	&fix_claim_all_hardfork,
+	&aspen_part_one_hardfork,
+	&aspen_part_two_hardfork,
+	&ficus_hardfork,
+	&cornus_hardfork,
+	&soleirolia_hardfork,
+	&berberis_hardfork,
+	&lantana_hardfork,
+	&rosa_hardfork,
+	&salvia_hardfork,
+	&tilia_hardfork,
+	&viola_hardfork,
+	&protea_hardfork,
+}
+
+// The EVM specs of the hardforks include the changes of the previous ones, so a hardfork can't be activated before them
+var ErrHardforksOrder = errors.New("hardforks are not activated in the registry order")
+
+func (c *HardforksConfig) validate() error {
+	for i := 1; i < len(hardfork_registry); i++ {
+		prev, hf := hardfork_registry[i-1], hardfork_registry[i]
+		if prev_blk_n, blk_n := prev.BlockNum(c), hf.BlockNum(c); blk_n < prev_blk_n {
+			return fmt.Errorf("%w: %s at block %d, %s at block %d", ErrHardforksOrder, prev.Name, prev_blk_n, hf.Name, blk_n)
+		}
+	}
+	return nil
+}
+
+func isForked(fork_start, block_num types.BlockNum) bool {
+	if fork_start == types.BlockNumberNIL || block_num == types.BlockNumberNIL {
+		return false
+	}
+	return fork_start <= block_num
+}
+
+// ActiveHardforks returns the names of the hardforks which are active at the block, in the registry order
+func (c *HardforksConfig) ActiveHardforks(num types.BlockNum) (ret []string) {
+	for _, hf := range hardfork_registry {
+		if isForked(hf.BlockNum(c), num) {
+			ret = append(ret, hf.Name)
+		}
+	}
+	return
+}
+
+func (c *HardforksConfig) Rules(num types.BlockNum) (rules vm.Rules) {
+	rules.Spec = vm.SpecCalifornicum
+	for _, hf := range hardfork_registry {
+		if !isForked(hf.BlockNum(c), num) {
+			continue
+		}
+		if hf.Rules != nil {
+			hf.Rules(c, &rules)
+		}
+		rules.Spec.Override(&hf.EVM)
+	}
+	return
+}
+
+// ApplyMigrations applies the migrations of the hardforks which start at the block
+func (c *HardforksConfig) ApplyMigrations(num types.BlockNum, state HardforkState) {
+	for _, hf := range hardfork_registry {
+		if hf.Migrate != nil && hf.BlockNum(c) == num {
+			hf.Migrate(c, state)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package chain_config
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+)
+
+func TestHardforkRegistry(t *testing.T) {
+	cfg := HardforksConfig{
+		FixRedelegateBlockNum:  types.BlockNumberNIL,
+		PhalaenopsisHfBlockNum: types.BlockNumberNIL,
+		FixClaimAllBlockNum:    types.BlockNumberNIL,
+		MagnoliaHf:             MagnoliaHfConfig{BlockNum: types.BlockNumberNIL},
+		AspenHf:                AspenHfConfig{BlockNumPartOne: types.BlockNumberNIL, BlockNumPartTwo: types.BlockNumberNIL},
+		FicusHf:                FicusHfConfig{BlockNum: 10},
+		CornusHf:               CornusHfConfig{BlockNum: types.BlockNumberNIL},
+		SoleiroliaHf:           SoleiroliaHfConfig{BlockNum: 20, TrxMaxGasLimit: 100},
+		BerberisHf:             BerberisHfConfig{BlockNum: 30},
+		LantanaHf:              LantanaHfConfig{BlockNum: 30},
+		RosaHf:                 RosaHfConfig{BlockNum: types.BlockNumberNIL},
+		SalviaHf:               SalviaHfConfig{BlockNum: types.BlockNumberNIL},
+		TiliaHf:                TiliaHfConfig{BlockNum: types.BlockNumberNIL},
+		ViolaHf:                ViolaHfConfig{BlockNum: types.BlockNumberNIL},
+		ProteaHf:               ProteaHfConfig{BlockNum: 25},
+	}
+	for _, c := range []struct {
+		blk_n  types.BlockNum
+		active []string
+		spec   vm.Spec
+	}{
+		{0, nil, vm.SpecCalifornicum},
+		{10, []string{"Ficus"}, vm.SpecFicus},
+		{20, []string{"Ficus", "Soleirolia"}, vm.SpecFicus},
+		{25, []string{"Ficus", "Soleirolia", "Protea"}, vm.SpecFicus},
+		{30, []string{"Ficus", "Soleirolia", "Berberis", "Lantana", "Protea"}, vm.SpecLantana},
+	} {
+		if active := cfg.ActiveHardforks(c.blk_n); !slices.Equal(active, c.active) {
+			t.Fatalf("block %d: active hardforks %v", c.blk_n, active)
+		}
+		rules := cfg.Rules(c.blk_n)
+		if rules.Spec != c.spec {
+			t.Fatalf("block %d: unexpected EVM spec", c.blk_n)
+		}
+		if rules.IsFicus != (c.blk_n >= 10) || rules.IsLantana != (c.blk_n >= 30) || rules.IsCornus {
+			t.Fatalf("block %d: rules %+v", c.blk_n, rules)
+		}
+		// the soleirolia limits are enforced since protea
+		if is_protea := c.blk_n >= 25; rules.IsProtea != is_protea || (rules.TrxMaxGasLimit == 100) != is_protea {
+			t.Fatalf("block %d: protea rules %+v", c.blk_n, rules)
+		}
+	}
+
+	// migrations are applied only in the first block of the hardfork
+	var migrated []types.BlockNum
+	hardfork_registry = append(hardfork_registry, &Hardfork{
+		Name:     "Test",
+		BlockNum: func(*HardforksConfig) types.BlockNum { return 15 },
+		Migrate:  func(*HardforksConfig, HardforkState) { migrated = append(migrated, 15) },
+	})
+	defer func() { hardfork_registry = hardfork_registry[:len(hardfork_registry)-1] }()
+	for blk_n := types.BlockNum(0); blk_n < 20; blk_n++ {
+		cfg.ApplyMigrations(blk_n, nil)
+	}
+	if !slices.Equal(migrated, []types.BlockNum{15}) {
+		t.Fatalf("migrations %v", migrated)
+	}
+	if active := cfg.ActiveHardforks(15); !slices.Contains(active, "Test") {
+		t.Fatalf("active hardforks %v", active)
+	}
+}
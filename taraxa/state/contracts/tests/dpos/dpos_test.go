@@ -7,20 +7,26 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/Taraxa-project/taraxa-evm/accounts/abi"
 	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/core"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
 	"github.com/Taraxa-project/taraxa-evm/core/vm"
 	"github.com/Taraxa-project/taraxa-evm/crypto"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/chain_config"
 	dpos "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/dpos/precompiled"
 	dpos_sol "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/dpos/solidity"
 	contract_storage "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/storage"
 	test_utils "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/tests"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/rewards_stats"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_dry_runner"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/trie"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util/bigutil"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
@@ -137,6 +143,9 @@ var (
 				TrxMinGasPrice: 1,
 				TrxMaxGasLimit: 1,
 			},
+			ProteaHf: chain_config.ProteaHfConfig{
+				BlockNum: types.BlockNumberNIL,
+			},
 		},
 	}
 )
@@ -212,6 +221,10 @@ func NewRewardsStats(author *common.Address) rewards_stats.RewardsStats {
 	return rewardsStats
 }
 
+func TestMain(m *testing.M) {
+	os.Exit(test_utils.RunWithAllBackends(m))
+}
+
 func TestProof(t *testing.T) {
 	pubkey, seckey := GenerateKeyPair()
 	addr := common.BytesToAddress(keccak256.Hash(pubkey[1:])[12:])
@@ -465,7 +478,7 @@ func TestUndelegateV2(t *testing.T) {
 // In pre magnolia hardfork code, validator was deleted if his total_stake & rewards_pool == 0
 // In post magnolia hardfork code, validator was deleted if his total_stake & rewards_pool & ongoing undelegations_count == 0
 func TestPreMagnoliaHfUndelegate(t *testing.T) {
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	cfg.Hardforks.MagnoliaHf.BlockNum = 1000
 
 	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, cfg)
@@ -493,7 +506,7 @@ func TestPreMagnoliaHfUndelegate(t *testing.T) {
 }
 
 func TestMagnoliaHardfork(t *testing.T) {
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	cfg.Hardforks.MagnoliaHf.BlockNum = 25
 
 	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, cfg)
@@ -601,7 +614,7 @@ func TestMagnoliaHardfork(t *testing.T) {
 }
 
 func TestCornusHardfork(t *testing.T) {
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	cfg.Hardforks.CornusHf.BlockNum = 10
 
 	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, cfg)
@@ -766,7 +779,7 @@ func TestConfirmUndelegate(t *testing.T) {
 }
 
 func TestConfirmUndelegateV2(t *testing.T) {
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	cfg.Hardforks.CornusHf.BlockNum = 0
 
 	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, cfg)
@@ -871,7 +884,7 @@ func TestCancelUndelegate(t *testing.T) {
 }
 
 func TestCancelUndelegateV2(t *testing.T) {
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	cfg.Hardforks.CornusHf.BlockNum = 0
 
 	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, cfg)
@@ -1354,7 +1367,7 @@ func TestRewardsAndCommission(t *testing.T) {
 }
 
 func TestClaimAllRewards(t *testing.T) {
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	cfg.DPOS.MinimumDeposit = big.NewInt(0)
 	cfg.Hardforks.AspenHf.BlockNumPartTwo = 1000
 
@@ -1411,7 +1424,7 @@ func TestClaimAllRewards(t *testing.T) {
 }
 
 func TestGenesis(t *testing.T) {
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 
 	delegator := addr(1)
 
@@ -1480,7 +1493,7 @@ func TestSetValidatorInfo(t *testing.T) {
 }
 
 func TestSetCommission(t *testing.T) {
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	cfg.DPOS.CommissionChangeDelta = 5
 	cfg.DPOS.CommissionChangeFrequency = 4
 
@@ -1532,7 +1545,7 @@ func TestGetValidators(t *testing.T) {
 	}
 
 	// Set some balance to validators
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	validator_balance := bigutil.Mul(big.NewInt(100000000), TaraPrecision)
 	for _, validator := range gen_validators {
 		cfg.GenesisBalances[validator.owner] = validator_balance
@@ -1615,7 +1628,7 @@ func TestGetValidatorsFor(t *testing.T) {
 	}
 
 	// Set some balance to validators
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	validator_balance := bigutil.Mul(big.NewInt(100000000), TaraPrecision)
 	for _, validator := range gen_validators {
 		cfg.GenesisBalances[validator.owner] = validator_balance
@@ -1687,7 +1700,7 @@ func TestGetTotalDelegation(t *testing.T) {
 	}
 
 	// Set some balance to validators
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	validator_balance := bigutil.Mul(big.NewInt(100000000), TaraPrecision)
 	for _, validator := range gen_validators {
 		cfg.GenesisBalances[validator.owner] = validator_balance
@@ -1743,7 +1756,7 @@ func TestGetDelegations(t *testing.T) {
 	}
 
 	// Set some balance to validators
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	validator_balance := bigutil.Mul(big.NewInt(100000000), TaraPrecision)
 	for _, validator := range gen_validators {
 		cfg.GenesisBalances[validator.owner] = validator_balance
@@ -1838,7 +1851,7 @@ func TestGetUndelegationsV1(t *testing.T) {
 	}
 
 	// Set some balance to validators
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	validator_balance := bigutil.Mul(big.NewInt(100000000), TaraPrecision)
 	for _, validator := range gen_validators {
 		cfg.GenesisBalances[validator.owner] = validator_balance
@@ -1954,7 +1967,7 @@ func TestGetUndelegationsV2(t *testing.T) {
 	}
 
 	// Set some balance to validators
-	cfg := DefaultChainCfg
+	cfg := CopyDefaultChainConfig()
 	validator_balance := bigutil.Mul(big.NewInt(100000000), TaraPrecision)
 	for _, validator := range gen_validators {
 		cfg.GenesisBalances[validator.owner] = validator_balance
@@ -2897,3 +2910,396 @@ func TestNonPayableMethods(t *testing.T) {
 		test.ExecuteAndCheck(caller, big.NewInt(1), test.MethodId(method), dpos.ErrNonPayableMethod, util.ErrorString(""))
 	}
 }
+
+func TestTrxLimits(t *testing.T) {
+	cfg := CopyDefaultChainConfig()
+	cfg.Hardforks.CornusHf.BlockNum = 0
+	cfg.Hardforks.SoleiroliaHf.TrxMinGasPrice = 1
+	cfg.Hardforks.SoleiroliaHf.TrxMaxGasLimit = 100000
+	cfg.Hardforks.ProteaHf.BlockNum = 2
+	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, cfg)
+	defer test.End()
+
+	sender := addr(1)
+	execute := func(gas uint64, gas_price *big.Int) vm.ExecutionResult {
+		nonce := bigutil.Add(test.GetNonce(sender), big.NewInt(1))
+		return test.ExecuteTransactions(&vm.Transaction{
+			Value:    big.NewInt(1),
+			To:       addr_p(2),
+			From:     sender,
+			Gas:      gas,
+			GasPrice: gas_price,
+			Nonce:    nonce,
+		})[0]
+	}
+
+	// the limits are not enforced before the protea hardfork
+	res := execute(100001, big.NewInt(0))
+	tc.Assert.Equal(util.ErrorString(""), res.ConsensusErr)
+	tc.Assert.Equal(big.NewInt(2), test.GetNonce(sender))
+
+	res = execute(21000, big.NewInt(0))
+	tc.Assert.Equal(vm.ErrGasPriceTooLow.Error(), res.ConsensusErr.Error())
+	tc.Assert.Equal(big.NewInt(4), test.GetNonce(sender))
+
+	res = execute(100001, big.NewInt(1))
+	tc.Assert.Equal(vm.ErrGasLimitTooHigh.Error(), res.ConsensusErr.Error())
+	tc.Assert.Equal(big.NewInt(6), test.GetNonce(sender))
+
+	res = execute(100000, big.NewInt(1))
+	tc.Assert.Equal(util.ErrorString(""), res.ConsensusErr)
+	tc.Assert.Equal(util.ErrorString(""), res.ExecutionErr)
+	tc.Assert.Equal(big.NewInt(8), test.GetNonce(sender))
+}
+
+func TestBlockGasLimit(t *testing.T) {
+	cfg := CopyDefaultChainConfig()
+	cfg.Hardforks.CornusHf.BlockNum = 0
+	cfg.Hardforks.CornusHf.PbftGasLimit = 50000
+	cfg.Hardforks.CornusHf.DagGasLimit = 30000
+	cfg.Hardforks.ProteaHf.BlockNum = 2
+	// only the gas limits are tested here
+	cfg.Hardforks.SoleiroliaHf.TrxMinGasPrice = 0
+	cfg.Hardforks.SoleiroliaHf.TrxMaxGasLimit = 0
+	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, cfg)
+	defer test.End()
+
+	transfer := func(from common.Address, gas uint64) *vm.Transaction {
+		return &vm.Transaction{
+			Value:    big.NewInt(1),
+			To:       addr_p(5),
+			From:     from,
+			Gas:      gas,
+			GasPrice: big.NewInt(0),
+			Nonce:    bigutil.Add(test.GetNonce(from), big.NewInt(1)),
+		}
+	}
+
+	// the limits are not enforced before the protea hardfork
+	res := test.ExecuteTransactions(transfer(addr(1), 40000), transfer(addr(2), 21000), transfer(addr(3), 21000))
+	for i := range res {
+		tc.Assert.Equal(util.ErrorString(""), res[i].ConsensusErr)
+	}
+	tc.Assert.Equal(uint64(63000), res[2].CumulativeGasUsed)
+
+	res = test.ExecuteTransactions(transfer(addr(1), 21000), transfer(addr(2), 21000), transfer(addr(3), 21000), transfer(addr(4), 8000))
+	tc.Assert.Equal(util.ErrorString(""), res[0].ConsensusErr)
+	tc.Assert.Equal(uint64(21000), res[0].CumulativeGasUsed)
+	tc.Assert.Equal(util.ErrorString(""), res[1].ConsensusErr)
+	tc.Assert.Equal(uint64(42000), res[1].CumulativeGasUsed)
+	tc.Assert.Equal(vm.ErrBlockGasLimitReached.Error(), res[2].ConsensusErr.Error())
+	tc.Assert.Equal(uint64(0), res[2].GasUsed)
+	tc.Assert.Equal(uint64(42000), res[2].CumulativeGasUsed)
+	// gas is not enough for a transfer, but the transaction still fits into the block
+	tc.Assert.Equal(vm.ErrIntrinsicGas.Error(), res[3].ConsensusErr.Error())
+	tc.Assert.Equal(uint64(50000), res[3].CumulativeGasUsed)
+
+	// counters are reset in the next block
+	res = test.ExecuteTransactions(transfer(addr(3), 21000))
+	tc.Assert.Equal(util.ErrorString(""), res[0].ConsensusErr)
+	tc.Assert.Equal(uint64(21000), res[0].CumulativeGasUsed)
+
+	// the gas limit of the block is enforced if it is lower than the PBFT one
+	res = test.ExecuteBlock(&vm.BlockInfo{GasLimit: 30000}, transfer(addr(1), 21000), transfer(addr(2), 21000))
+	tc.Assert.Equal(util.ErrorString(""), res[0].ConsensusErr)
+	tc.Assert.Equal(vm.ErrBlockGasLimitReached.Error(), res[1].ConsensusErr.Error())
+
+	// transaction can't have more gas than a DAG block
+	res = test.ExecuteTransactions(transfer(addr(1), 30001))
+	tc.Assert.Equal(vm.ErrGasLimitTooHigh.Error(), res[0].ConsensusErr.Error())
+}
+
+func TestEstimateGas(t *testing.T) {
+	cfg := CopyDefaultChainConfig()
+	// reverts if transient slot 0 is set, sets it otherwise
+	tstore_addr := common.Address{0x75}
+	cfg.GenesisAlloc = chain_config.GenesisAlloc{tstore_addr: {Code: common.Hex2Bytes("6000b3600c5760016000b4005b600080fd")}}
+	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, cfg)
+	defer test.End()
+
+	val_owner := addr(1)
+	val_addr, proof := generateAddrAndProof()
+	register := func() *vm.Transaction {
+		return &vm.Transaction{
+			Value:    DefaultMinimumDeposit,
+			To:       dpos.ContractAddress(),
+			From:     val_owner,
+			Input:    test.Pack("registerValidator", val_addr, proof, DefaultVrfKey, uint16(10), "test", "test"),
+			GasPrice: big.NewInt(0),
+		}
+	}
+	estimate := func(trx *vm.Transaction) state_dry_runner.GasEstimation {
+		return test.SUT.EstimateGas(&vm.Block{Number: test.BlockNumber()}, trx, 1000000)
+	}
+
+	transfer := estimate(&vm.Transaction{Value: big.NewInt(1), To: addr_p(2), From: val_owner, GasPrice: big.NewInt(0)})
+	tc.Assert.Equal(util.ErrorString(""), transfer.ExecutionErr)
+	tc.Assert.Equal(uint64(21000), transfer.Gas)
+
+	// zero cap means the block gas limit, if it is not set either the estimation still works
+	transfer = test.SUT.EstimateGas(&vm.Block{Number: test.BlockNumber()}, &vm.Transaction{Value: big.NewInt(1), To: addr_p(2), From: val_owner, GasPrice: big.NewInt(0)}, 0)
+	tc.Assert.Equal(util.ErrorString(""), transfer.ExecutionErr)
+	tc.Assert.Equal(uint64(21000), transfer.Gas)
+
+	// transient storage doesn't leak between the executions of the estimation
+	tstore := estimate(&vm.Transaction{Value: big.NewInt(0), To: &tstore_addr, From: val_owner, GasPrice: big.NewInt(0)})
+	tc.Assert.Equal(util.ErrorString(""), tstore.ExecutionErr)
+	tc.Assert.Less(tstore.Gas, uint64(30000))
+
+	estimation := estimate(register())
+	tc.Assert.Equal(util.ErrorString(""), estimation.ExecutionErr)
+	tc.Assert.Equal(util.ErrorString(""), estimation.ConsensusErr)
+
+	// estimated gas is the minimal one
+	trx := register()
+	trx.Gas, trx.Nonce = estimation.Gas-1, bigutil.Add(test.GetNonce(val_owner), big.NewInt(1))
+	tc.Assert.Equal(vm.ErrOutOfGas.Error(), test.ExecuteTransactions(trx)[0].ExecutionErr.Error())
+	trx = register()
+	trx.Gas, trx.Nonce = estimation.Gas, bigutil.Add(test.GetNonce(val_owner), big.NewInt(1))
+	tc.Assert.Equal(util.ErrorString(""), test.ExecuteTransactions(trx)[0].ExecutionErr)
+
+	// validator is already registered, so the transaction fails with any gas
+	estimation = estimate(register())
+	tc.Assert.Equal(uint64(1000000), estimation.Gas)
+	tc.Assert.Equal(dpos.ErrExistentValidator, estimation.ExecutionErr)
+}
+
+func TestDryRunOverrides(t *testing.T) {
+	contract_addr, sender := common.Address{0xcc}, common.Address{0xee}
+	// returns storage slot 0
+	sload_code := common.Hex2Bytes("60005460005260206000f3")
+	stored_addr, stored_value := common.Address{0xcd}, common.BytesToHash(big.NewInt(7).Bytes())
+	cfg := CopyDefaultChainConfig()
+	cfg.GenesisAlloc = chain_config.GenesisAlloc{stored_addr: {Code: sload_code, Storage: map[common.Hash]common.Hash{{}: stored_value}}}
+	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, cfg)
+	defer test.End()
+	// returns block number and coinbase
+	block_code := common.Hex2Bytes("436000524160205260406000f3")
+	call_to := func(to common.Address, state_overrides state_dry_runner.StateOverrides, block_overrides *state_dry_runner.BlockOverrides) vm.ExecutionResult {
+		return test.SUT.DryRunTransaction(&vm.Block{Number: test.BlockNumber()}, &vm.Transaction{
+			Value:    big.NewInt(1000),
+			To:       &to,
+			From:     sender,
+			Gas:      100000,
+			GasPrice: big.NewInt(0),
+		}, state_overrides, block_overrides)
+	}
+	call := func(state_overrides state_dry_runner.StateOverrides, block_overrides *state_dry_runner.BlockOverrides) vm.ExecutionResult {
+		return call_to(contract_addr, state_overrides, block_overrides)
+	}
+
+	// sender has no balance without overrides
+	tc.Assert.Equal(vm.ErrInsufficientBalanceForTransfer.Error(), call(nil, nil).ConsensusErr.Error())
+
+	slot_0, slot_1 := common.Hash{}, common.BytesToHash(big.NewInt(1).Bytes())
+	res := call(state_dry_runner.StateOverrides{
+		{Address: sender, Balance: big.NewInt(1000)},
+		{Address: contract_addr, Code: sload_code, StateDiff: []state_dry_runner.StorageEntry{{slot_0, common.BytesToHash(big.NewInt(42).Bytes())}}},
+	}, nil)
+	tc.Assert.Equal(util.ErrorString(""), res.ConsensusErr)
+	tc.Assert.Equal(common.BytesToHash(big.NewInt(42).Bytes()).Bytes(), res.CodeRetval)
+
+	// full storage replacement drops the rest of the slots
+	res = call(state_dry_runner.StateOverrides{
+		{Address: sender, Balance: big.NewInt(1000)},
+		{Address: contract_addr, Code: sload_code, State: []state_dry_runner.StorageEntry{{slot_1, common.BytesToHash(big.NewInt(1).Bytes())}}},
+	}, nil)
+	tc.Assert.Equal(util.ErrorString(""), res.ConsensusErr)
+	tc.Assert.Equal(common.Hash{}.Bytes(), res.CodeRetval)
+
+	// empty state is no override unless the replacement is requested, then it wipes the storage
+	rich_sender := state_dry_runner.AccountOverride{Address: sender, Balance: big.NewInt(1000)}
+	res = call_to(stored_addr, state_dry_runner.StateOverrides{rich_sender, {Address: stored_addr, State: []state_dry_runner.StorageEntry{}}}, nil)
+	tc.Assert.Equal(util.ErrorString(""), res.ConsensusErr)
+	tc.Assert.Equal(stored_value.Bytes(), res.CodeRetval)
+	res = call_to(stored_addr, state_dry_runner.StateOverrides{rich_sender, {Address: stored_addr, ReplaceState: true}}, nil)
+	tc.Assert.Equal(util.ErrorString(""), res.ConsensusErr)
+	tc.Assert.Equal(common.Hash{}.Bytes(), res.CodeRetval)
+
+	author := common.Address{0xaa}
+	res = call(state_dry_runner.StateOverrides{
+		{Address: sender, Balance: big.NewInt(1000)},
+		{Address: contract_addr, Code: block_code},
+	}, &state_dry_runner.BlockOverrides{Number: 1000, Author: &author})
+	tc.Assert.Equal(util.ErrorString(""), res.ConsensusErr)
+	tc.Assert.Equal(append(common.BytesToHash(big.NewInt(1000).Bytes()).Bytes(), common.BytesToHash(author[:]).Bytes()...), res.CodeRetval)
+
+	// overrides don't touch the db
+	tc.Assert.Nil(test.GetBalance(&sender))
+}
+
+func TestSimulate(t *testing.T) {
+	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, CopyDefaultChainConfig())
+	defer test.End()
+
+	val_owner, delegator := addr(1), addr(2)
+	val_addr, proof := generateAddrAndProof()
+	receiver_1, receiver_2 := common.Address{0xee, 1}, common.Address{0xee, 2}
+	transfer := func(from common.Address, to *common.Address, value int64) state_dry_runner.SimulatedTransaction {
+		return state_dry_runner.SimulatedTransaction{vm.Transaction{Value: big.NewInt(value), To: to, From: from, Gas: 100000, GasPrice: big.NewInt(0)}, true}
+	}
+	dpos_call := func(from common.Address, value *big.Int, input []byte) state_dry_runner.SimulatedTransaction {
+		return state_dry_runner.SimulatedTransaction{vm.Transaction{Value: value, To: dpos.ContractAddress(), From: from, Input: input, Gas: 1000000, GasPrice: big.NewInt(0)}, true}
+	}
+	// explicit nonce is kept
+	transfer_back := transfer(receiver_2, &receiver_1, 100)
+	transfer_back.Nonce, transfer_back.AutoNonce = big.NewInt(5), false
+	blocks := []state_dry_runner.SimulatedBlock{
+		{Transactions: []state_dry_runner.SimulatedTransaction{
+			dpos_call(val_owner, DefaultMinimumDeposit, test.Pack("registerValidator", val_addr, proof, DefaultVrfKey, uint16(10), "test", "test")),
+			transfer(val_owner, &receiver_1, 1000),
+		}},
+		// transactions depend on the changes of the previous block
+		{BlockOverrides: &state_dry_runner.BlockOverrides{Time: 100}, Transactions: []state_dry_runner.SimulatedTransaction{
+			transfer(receiver_1, &receiver_2, 400),
+			dpos_call(delegator, DefaultMinimumDeposit, test.Pack("delegate", val_addr)),
+			transfer_back,
+		}},
+	}
+	// blocks are passed RLP encoded, nil nonces are decoded as zero ones
+	blocks_enc, err := rlp.EncodeToBytes(blocks)
+	tc.Assert.NoError(err)
+	blocks = nil
+	tc.Assert.NoError(rlp.DecodeBytes(blocks_enc, &blocks))
+	tc.Assert.Equal(big.NewInt(0), blocks[0].Transactions[0].Nonce)
+	owner_balance, delegator_balance := test.GetBalance(&val_owner), test.GetBalance(&delegator)
+	ret := test.SUT.Simulate(&vm.Block{Number: test.BlockNumber()}, blocks, nil, state_dry_runner.SimulationOpts{EndBlockCalls: true, ReturnStateDiff: true})
+
+	tc.Assert.Equal(2, len(ret.Blocks))
+	tc.Assert.Equal(test.BlockNumber(), ret.Blocks[0].Number)
+	tc.Assert.Equal(test.BlockNumber()+1, ret.Blocks[1].Number)
+	tc.Assert.Equal(uint64(100), ret.Blocks[1].Time)
+	for _, blk := range ret.Blocks {
+		for _, res := range blk.Results {
+			tc.Assert.Equal(util.ErrorString(""), res.ConsensusErr)
+			tc.Assert.Equal(util.ErrorString(""), res.ExecutionErr)
+		}
+		tc.Assert.Equal(blk.Results[0].GasUsed, blk.Results[0].CumulativeGasUsed)
+		tc.Assert.Equal(blk.GasUsed, blk.Results[len(blk.Results)-1].CumulativeGasUsed)
+	}
+	tc.Assert.Equal(2, len(ret.Blocks[0].Results))
+	tc.Assert.Equal(3, len(ret.Blocks[1].Results))
+	// registration and delegation emit events
+	tc.Assert.NotEmpty(ret.Blocks[0].Results[0].Logs)
+	tc.Assert.NotEmpty(ret.Blocks[1].Results[1].Logs)
+	// logs are per transaction
+	tc.Assert.Empty(ret.Blocks[1].Results[0].Logs)
+
+	diff := make(map[common.Address]state_dry_runner.AccountDiff)
+	for _, acc := range ret.StateDiff {
+		diff[acc.Address] = acc
+	}
+	tc.Assert.Equal(big.NewInt(700), diff[receiver_1].Balance)
+	tc.Assert.Equal(big.NewInt(300), diff[receiver_2].Balance)
+	// senders without nonce get the next one
+	tc.Assert.Equal(big.NewInt(2), diff[receiver_1].Nonce)
+	tc.Assert.Equal(big.NewInt(6), diff[receiver_2].Nonce)
+	tc.Assert.Equal(bigutil.Sub(bigutil.Sub(owner_balance, DefaultMinimumDeposit), big.NewInt(1000)), diff[val_owner].Balance)
+	tc.Assert.Equal(bigutil.Sub(delegator_balance, DefaultMinimumDeposit), diff[delegator].Balance)
+	tc.Assert.Contains(diff, *dpos.ContractAddress())
+
+	// nothing is written to the db
+	tc.Assert.Nil(test.GetBalance(&receiver_1))
+	tc.Assert.Equal(owner_balance, test.GetBalance(&val_owner))
+}
+
+func TestGetProof(t *testing.T) {
+	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, CopyDefaultChainConfig())
+	defer test.End()
+
+	// stores 42 to the slot 0 and 1 to the slot 1, deployed code is 0x01
+	init_code := common.Hex2Bytes("602a6000556001600155600160005360016000f3")
+	res := test.ExecuteTransactions(&vm.Transaction{
+		From:     addr(1),
+		Value:    big.NewInt(0),
+		Input:    init_code,
+		Gas:      1000000,
+		GasPrice: big.NewInt(0),
+		Nonce:    bigutil.Add(test.GetNonce(addr(1)), big.NewInt(1)),
+	})[0]
+	tc.Assert.Equal(util.ErrorString(""), res.ExecutionErr)
+	contract_addr := res.NewContractAddr
+
+	state_root := test.SUT.GetCommittedStateDescriptor().StateRoot
+	get_proof := func(addr *common.Address, keys []common.Hash) state_db.AccountProof {
+		proof, err := test.SUT.GetProof(test.BlockNumber(), addr, keys)
+		tc.Assert.NoError(err)
+		return proof
+	}
+	slot_0, slot_1, slot_absent := common.Hash{}, common.BytesToHash([]byte{1}), common.BytesToHash([]byte{2})
+	proof := get_proof(&contract_addr, []common.Hash{slot_0, slot_1, slot_absent})
+	tc.Assert.NoError(proof.VerifyProof(&state_root))
+	tc.Assert.Equal(big.NewInt(42), proof.StorageProof[0].Value)
+	tc.Assert.Equal(big.NewInt(1), proof.StorageProof[1].Value)
+	tc.Assert.Equal(big.NewInt(0), proof.StorageProof[2].Value)
+	tc.Assert.NotEmpty(proof.StorageProof[2].Proof)
+	tc.Assert.Equal(*keccak256.Hash([]byte{1}), proof.CodeHash)
+
+	proof = get_proof(addr_p(1), nil)
+	tc.Assert.NoError(proof.VerifyProof(&state_root))
+	tc.Assert.Equal(test.GetBalance(addr_p(1)), proof.Balance)
+
+	// proof of an absent account
+	absent := common.Address{0xab}
+	proof = get_proof(&absent, []common.Hash{slot_0})
+	tc.Assert.NoError(proof.VerifyProof(&state_root))
+	tc.Assert.Equal(big.NewInt(0), proof.Balance)
+	tc.Assert.Empty(proof.StorageProof[0].Proof)
+
+	// modified values don't match the proofs
+	proof = get_proof(&contract_addr, []common.Hash{slot_0})
+	proof.StorageProof[0].Value = big.NewInt(43)
+	tc.Assert.Equal(state_db.ErrStorageProofMismatch, proof.VerifyProof(&state_root))
+	proof.Balance = big.NewInt(1)
+	tc.Assert.Equal(state_db.ErrAccountProofMismatch, proof.VerifyProof(&state_root))
+	proof.AccountProof = proof.AccountProof[:1]
+	tc.Assert.Equal(trie.ErrProofNodeMissing, proof.VerifyProof(&state_root))
+
+	// the state root of a block which is not committed is unknown
+	_, err := test.SUT.GetProof(test.BlockNumber()+1, &contract_addr, nil)
+	tc.Assert.Equal(state_db.ErrStateRootUnknown, err)
+}
+
+func TestRevertTo(t *testing.T) {
+	tc, test := test_utils.Init_test(dpos.ContractAddress(), dpos_sol.TaraxaDposClientMetaData, t, CopyDefaultChainConfig())
+	defer test.End()
+
+	transfer := func(value int64) {
+		test.ExecuteTransactions(&vm.Transaction{
+			From:     addr(1),
+			To:       addr_p(2),
+			Value:    big.NewInt(value),
+			Gas:      1000000,
+			GasPrice: big.NewInt(0),
+			Nonce:    bigutil.Add(test.GetNonce(addr(1)), big.NewInt(1)),
+		})
+	}
+	transfer(1)
+	blk_n := test.BlockNumber()
+	state_desc := test.SUT.GetCommittedStateDescriptor()
+	balance_1, balance_2, nonce_1 := test.GetBalance(addr_p(1)), test.GetBalance(addr_p(2)), test.GetNonce(addr(1))
+	// new account is created after blk_n
+	test.ExecuteTransactions(&vm.Transaction{
+		From:     addr(1),
+		To:       &common.Address{0xab},
+		Value:    big.NewInt(5),
+		Gas:      1000000,
+		GasPrice: big.NewInt(0),
+		Nonce:    bigutil.Add(test.GetNonce(addr(1)), big.NewInt(1)),
+	})
+	transfer(10)
+	transfer(100)
+
+	tc.Assert.Equal(state_db.ErrRevertToFutureBlock, test.RevertTo(test.BlockNumber()+1))
+	tc.Assert.NoError(test.RevertTo(blk_n))
+	tc.Assert.Equal(state_desc, test.SUT.GetCommittedStateDescriptor())
+	tc.Assert.Equal(balance_1, test.GetBalance(addr_p(1)))
+	tc.Assert.Equal(balance_2, test.GetBalance(addr_p(2)))
+	tc.Assert.Equal(nonce_1, test.GetNonce(addr(1)))
+	tc.Assert.Nil(test.GetBalance(&common.Address{0xab}))
+
+	// blocks are executed on top of the reverted state
+	transfer(1000)
+	tc.Assert.Equal(state_desc.BlockNum+1, test.SUT.GetCommittedStateDescriptor().BlockNum)
+	tc.Assert.Equal(bigutil.Add(balance_2, big.NewInt(1000)), test.GetBalance(addr_p(2)))
+}
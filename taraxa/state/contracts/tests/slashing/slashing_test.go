@@ -5,11 +5,13 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"math/big"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/Taraxa-project/taraxa-evm/accounts/abi"
 	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
 	"github.com/Taraxa-project/taraxa-evm/crypto/secp256k1"
 	"github.com/Taraxa-project/taraxa-evm/rlp"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/chain_config"
@@ -81,6 +83,9 @@ var (
 				TrxMinGasPrice: 1,
 				TrxMaxGasLimit: 1,
 			},
+			ProteaHf: chain_config.ProteaHfConfig{
+				BlockNum: types.BlockNumberNIL,
+			},
 		},
 	}
 )
@@ -124,6 +129,10 @@ func GetVoteRlp(vote *slashing.Vote) []byte {
 	return rlp.MustEncodeToBytes(vote)
 }
 
+func TestMain(m *testing.M) {
+	os.Exit(test_utils.RunWithAllBackends(m))
+}
+
 func TestDoubleVotingSameVotesHashes(t *testing.T) {
 	tc, test := test_utils.Init_test(slashing.ContractAddress(), slashing_sol.TaraxaSlashingClientMetaData, t, DefaultChainCfg)
 	defer test.End()
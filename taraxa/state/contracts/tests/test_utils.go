@@ -14,6 +14,7 @@ import (
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/rewards_stats"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
 
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db_memory"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db_rocksdb"
 
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util/tests"
@@ -26,11 +27,38 @@ import (
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/chain_config"
 )
 
+// Backend is the state db implementation the contract tests are run with
+type Backend int
+
+const (
+	RocksDB Backend = iota
+	Memory
+)
+
+// StateDBBackend is the backend of the tests initialized with Init_test, see RunWithAllBackends
+var StateDBBackend = RocksDB
+
+// RunWithAllBackends runs the tests of the package once per backend, it is meant to be called from TestMain
+func RunWithAllBackends(m *testing.M) (code int) {
+	for _, backend := range []Backend{RocksDB, Memory} {
+		StateDBBackend = backend
+		if code = m.Run(); code != 0 {
+			return
+		}
+	}
+	return
+}
+
+type StateDB interface {
+	state.DB
+	Close()
+}
+
 type ContractTest struct {
 	Chain_cfg     chain_config.ChainConfig
 	St            state.StateTransition
 	contract_addr common.Address
-	Statedb       *state_db_rocksdb.DB
+	Statedb       StateDB
 	tc            *tests.TestCtx
 	SUT           *state.API
 	blk_n         types.BlockNum
@@ -52,9 +80,14 @@ func (self *ContractTest) init(contract_addr common.Address, contract_abi string
 	self.tc = t
 	self.Chain_cfg = cfg
 
-	self.Statedb = new(state_db_rocksdb.DB).Init(state_db_rocksdb.Opts{
-		Path: self.tc.DataDir(),
-	})
+	switch StateDBBackend {
+	case RocksDB:
+		self.Statedb = new(state_db_rocksdb.DB).Init(state_db_rocksdb.Opts{
+			Path: self.tc.DataDir(),
+		})
+	case Memory:
+		self.Statedb = new(state_db_memory.DB).Init()
+	}
 	self.SUT = new(state.API).Init(
 		self.Statedb,
 		func(num types.BlockNum) *big.Int { panic("unexpected") },
@@ -71,10 +104,7 @@ func (self *ContractTest) execute(from common.Address, value *big.Int, input []b
 	senderNonce := self.GetNonce(from)
 	senderNonce.Add(senderNonce, big.NewInt(1))
 
-	self.blk_n++
-	self.St.BeginBlock(&vm.BlockInfo{})
-
-	res := self.St.ExecuteTransaction(&vm.Transaction{
+	return self.ExecuteTransactions(&vm.Transaction{
 		Value:    value,
 		To:       &self.contract_addr,
 		From:     from,
@@ -82,12 +112,24 @@ func (self *ContractTest) execute(from common.Address, value *big.Int, input []b
 		Gas:      1000000,
 		GasPrice: big.NewInt(0),
 		Nonce:    senderNonce,
-	})
+	})[0]
+}
 
+// ExecuteTransactions executes the transactions as is in a new block
+func (self *ContractTest) ExecuteTransactions(trxs ...*vm.Transaction) []vm.ExecutionResult {
+	return self.ExecuteBlock(&vm.BlockInfo{}, trxs...)
+}
+
+// ExecuteBlock executes the transactions as is in a new block with the given info
+func (self *ContractTest) ExecuteBlock(blk_info *vm.BlockInfo, trxs ...*vm.Transaction) (ret []vm.ExecutionResult) {
+	self.blk_n++
+	self.St.BeginBlock(blk_info)
+	for _, trx := range trxs {
+		ret = append(ret, self.St.ExecuteTransaction(trx))
+	}
 	self.St.EndBlock()
 	self.St.Commit()
-
-	return res
+	return
 }
 
 func (self *ContractTest) AdvanceBlock(author *common.Address, rewardsStats *rewards_stats.RewardsStats) (ret *uint256.Int) {
@@ -95,7 +137,7 @@ func (self *ContractTest) AdvanceBlock(author *common.Address, rewardsStats *rew
 	if author == nil {
 		self.St.BeginBlock(&vm.BlockInfo{})
 	} else {
-		self.St.BeginBlock(&vm.BlockInfo{*author, 0, 0, nil})
+		self.St.BeginBlock(&vm.BlockInfo{Author: *author})
 	}
 	ret = self.St.DistributeRewards(rewardsStats)
 	self.St.EndBlock()
@@ -103,6 +145,14 @@ func (self *ContractTest) AdvanceBlock(author *common.Address, rewardsStats *rew
 	return
 }
 
+func (self *ContractTest) RevertTo(blk_n types.BlockNum) error {
+	if err := self.SUT.RevertTo(blk_n); err != nil {
+		return err
+	}
+	self.blk_n = blk_n
+	return nil
+}
+
 func (self *ContractTest) GetBalance(account *common.Address) *big.Int {
 	var bal_actual *big.Int
 	self.SUT.ReadBlock(self.blk_n).GetAccount(account, func(account state_db.Account) {
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/chain_config"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db_memory"
+)
+
+func TestGenesisAlloc(t *testing.T) {
+	genesis := `{
+		"config": {"chainId": 1},
+		"alloc": {
+			"0x0000000000000000000000000000000000000001": {"balance": "1000"},
+			"0000000000000000000000000000000000000002": {
+				"balance": "0x10",
+				"nonce": "0x5",
+				"code": "0x600160005260206000f3",
+				"storage": {
+					"0x0000000000000000000000000000000000000000000000000000000000000001": "0x00000000000000000000000000000000000000000000000000000000000000ff",
+					"0x02": "0x0102",
+					"0x3": "0x"
+				}
+			}
+		}
+	}`
+	alloc, err := chain_config.GenesisAllocFromJSON([]byte(genesis))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain_config.GenesisAllocFromJSON([]byte(`{"0x01": {"balance": "1"}}`)); err == nil {
+		t.Fatal("invalid address is accepted")
+	}
+	if _, err := chain_config.GenesisAllocFromJSON([]byte(`{"0x0000000000000000000000000000000000000001": {"storage": {"0x01": "0xzz"}}}`)); err == nil {
+		t.Fatal("invalid storage value is accepted")
+	}
+	// short keys and values are padded on the left
+	if storage := alloc[common.Address{19: 2}].Storage; len(storage) != 3 ||
+		storage[common.Hash{31: 2}] != (common.Hash{30: 1, 31: 2}) || storage[common.Hash{31: 3}] != (common.Hash{}) {
+		t.Fatalf("genesis storage %v", storage)
+	}
+	var cfg chain_config.ChainConfig
+	cfg.GenesisAlloc = alloc
+	cfg.DPOS = chain_config.DPOSConfig{
+		EligibilityBalanceThreshold: big.NewInt(1),
+		VoteEligibilityBalanceStep:  big.NewInt(1),
+		ValidatorMaximumStake:       big.NewInt(1e18),
+		MinimumDeposit:              big.NewInt(1),
+		BlocksPerYear:               1000,
+	}
+	cfg.Hardforks.AspenHf = chain_config.AspenHfConfig{MaxSupply: big.NewInt(1e18), GeneratedRewards: big.NewInt(0)}
+	if sum := cfg.GenesisBalancesSum(); sum.Cmp(big.NewInt(1016)) != 0 {
+		t.Fatalf("genesis balances sum %v", sum)
+	}
+	api := new(API).Init(new(state_db_memory.DB).Init(), func(types.BlockNum) *big.Int { panic("unexpected") }, &cfg, APIOpts{})
+	defer api.Close()
+
+	reader := api.ReadBlock(0)
+	read_account := func(addr common.Address) (ret state_db.Account) {
+		reader.GetAccount(&addr, func(acc state_db.Account) { ret = acc })
+		return
+	}
+	if acc := read_account(common.Address{19: 1}); acc.Balance == nil || acc.Balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("account 1: %+v", acc)
+	}
+	contract := common.Address{19: 2}
+	if acc := read_account(contract); acc.Balance.Cmp(big.NewInt(16)) != 0 || acc.Nonce.Cmp(big.NewInt(5)) != 0 {
+		t.Fatalf("account 2: %+v", acc)
+	}
+	if code := reader.GetCodeByAddress(&contract); !bytes.Equal(code, common.Hex2Bytes("600160005260206000f3")) {
+		t.Fatalf("code %x", code)
+	}
+	var value []byte
+	reader.GetAccountStorage(&contract, &common.Hash{31: 1}, func(v []byte) { value = common.CopyBytes(v) })
+	if !bytes.Equal(value, []byte{0xff}) {
+		t.Fatalf("storage value %x", value)
+	}
+	reader.GetAccountStorage(&contract, &common.Hash{31: 2}, func(v []byte) { value = common.CopyBytes(v) })
+	if !bytes.Equal(value, []byte{1, 2}) {
+		t.Fatalf("storage value of the short key %x", value)
+	}
+}
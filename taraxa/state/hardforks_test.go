@@ -0,0 +1,433 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+	"github.com/Taraxa-project/taraxa-evm/crypto/secp256k1"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/chain_config"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db_memory"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+)
+
+// hardfork_test executes transactions in consecutive blocks on top of the genesis alloc
+type hardfork_test struct {
+	t      *testing.T
+	api    *API
+	sender common.Address
+	nonce  int64
+}
+
+func new_hardfork_test(t *testing.T, alloc chain_config.GenesisAlloc, set_hardforks func(*chain_config.HardforksConfig)) *hardfork_test {
+	cfg := new(chain_config.ChainConfig)
+	cfg.GenesisAlloc = alloc
+	cfg.DPOS = chain_config.DPOSConfig{
+		EligibilityBalanceThreshold: big.NewInt(1),
+		VoteEligibilityBalanceStep:  big.NewInt(1),
+		ValidatorMaximumStake:       big.NewInt(1e18),
+		MinimumDeposit:              big.NewInt(1),
+		BlocksPerYear:               1000,
+	}
+	cfg.Hardforks.AspenHf = chain_config.AspenHfConfig{MaxSupply: big.NewInt(1e18), GeneratedRewards: big.NewInt(0)}
+	// the hardforks under test are disabled unless they are enabled by set_hardforks
+	cfg.Hardforks.RosaHf.BlockNum, cfg.Hardforks.SalviaHf.BlockNum = types.BlockNumberNIL, types.BlockNumberNIL
+	cfg.Hardforks.TiliaHf.BlockNum, cfg.Hardforks.ViolaHf.BlockNum = types.BlockNumberNIL, types.BlockNumberNIL
+	set_hardforks(&cfg.Hardforks)
+	self := &hardfork_test{t: t, sender: common.HexToAddress("0x7e57")}
+	self.api = new(API).Init(new(state_db_memory.DB).Init(), func(types.BlockNum) *big.Int { panic("unexpected") }, cfg, APIOpts{})
+	return self
+}
+
+func (self *hardfork_test) close() {
+	self.api.Close()
+}
+
+func (self *hardfork_test) trx(to *common.Address, input []byte, access_list vm.AccessList) vm.Transaction {
+	self.nonce++
+	return vm.Transaction{
+		From:       self.sender,
+		GasPrice:   big.NewInt(0),
+		To:         to,
+		Nonce:      big.NewInt(self.nonce),
+		Value:      big.NewInt(0),
+		Gas:        1_000_000,
+		Input:      input,
+		AccessList: access_list,
+	}
+}
+
+// block executes the transactions in the next block and commits it
+func (self *hardfork_test) block(trxs ...vm.Transaction) (ret []vm.ExecutionResult) {
+	st := self.api.GetStateTransition()
+	st.BeginBlock(&vm.BlockInfo{GasLimit: 100_000_000, Difficulty: big.NewInt(0)})
+	for i := range trxs {
+		ret = append(ret, st.ExecuteTransaction(&trxs[i]))
+	}
+	st.EndBlock()
+	st.Commit()
+	return
+}
+
+// Test cases of EIP-3529, the slot is warm
+func TestEIP3529(t *testing.T) {
+	cases := []struct {
+		code     string
+		used     uint64
+		refund   uint64
+		original byte
+	}{
+		{"0x60006000556000600055", 212, 0, 0},
+		{"0x60006000556001600055", 20112, 0, 0},
+		{"0x60016000556000600055", 20112, 19900, 0},
+		{"0x60016000556002600055", 20112, 0, 0},
+		{"0x60016000556001600055", 20112, 0, 0},
+		{"0x60006000556000600055", 3012, 4800, 1},
+		{"0x60006000556001600055", 3012, 2800, 1},
+		{"0x60006000556002600055", 3012, 0, 1},
+		{"0x60026000556000600055", 3012, 4800, 1},
+		{"0x60026000556003600055", 3012, 0, 1},
+		{"0x60026000556001600055", 3012, 2800, 1},
+		{"0x60026000556002600055", 3012, 0, 1},
+		{"0x60016000556000600055", 3012, 4800, 1},
+		{"0x60016000556002600055", 3012, 0, 1},
+		{"0x60016000556001600055", 212, 0, 1},
+		{"0x600160005560006000556001600055", 40118, 19900, 0},
+		{"0x600060005560016000556000600055", 5918, 7600, 1},
+	}
+	alloc := make(chain_config.GenesisAlloc)
+	for i, c := range cases {
+		acc := chain_config.GenesisAccount{Code: common.FromHex(c.code)}
+		if c.original != 0 {
+			acc.Storage = map[common.Hash]common.Hash{{}: {31: c.original}}
+		}
+		alloc[common.Address{0: 0xc0, 19: byte(i + 1)}] = acc
+	}
+	// SELFDESTRUCT to the caller, which is warm. The contracts are executed before and after the hardfork
+	pre_fork_suicide, suicide := common.Address{0: 0xc1}, common.Address{0: 0xc2}
+	alloc[pre_fork_suicide] = chain_config.GenesisAccount{Code: common.FromHex("0x33ff")}
+	alloc[suicide] = alloc[pre_fork_suicide]
+
+	test := new_hardfork_test(t, alloc, func(hf *chain_config.HardforksConfig) { hf.RosaHf.BlockNum = 2 })
+	defer test.close()
+	res := test.block(test.trx(&pre_fork_suicide, nil, nil))[0]
+	if exp := vm.TxGas + 5002 - (vm.TxGas+5002)/vm.RefundQuotient; res.GasUsed != exp {
+		t.Fatalf("suicide before the hardfork: gas used %d, expected %d", res.GasUsed, exp)
+	}
+
+	var trxs []vm.Transaction
+	for i := range cases {
+		addr := common.Address{0: 0xc0, 19: byte(i + 1)}
+		trxs = append(trxs, test.trx(&addr, nil, vm.AccessList{{Address: addr, StorageKeys: []common.Hash{{}}}}))
+	}
+	trxs = append(trxs, test.trx(&suicide, nil, nil))
+	results := test.block(trxs...)
+	for i, c := range cases {
+		used := vm.TxGas + vm.TxAccessListAddressGas + vm.TxAccessListStorageKeyGas + c.used
+		exp := used - min(c.refund, used/vm.RefundQuotientEIP3529)
+		if res := results[i]; res.GasUsed != exp || res.ExecutionErr != "" || res.ConsensusErr != "" {
+			t.Fatalf("case %d %s: gas used %d, expected %d (%+v)", i, c.code, res.GasUsed, exp, res)
+		}
+	}
+	if res := results[len(cases)]; res.GasUsed != vm.TxGas+5002 {
+		t.Fatalf("suicide is refunded: gas used %d", res.GasUsed)
+	}
+}
+
+func TestEIP3860(t *testing.T) {
+	// CREATE and CREATE2 of the zero initcode of the size from the calldata, the result is stored to the slot 0
+	create := common.FromHex("0x60003560006000f060005500")
+	create2 := common.FromHex("0x600060003560006000f560005500")
+	alloc := make(chain_config.GenesisAlloc)
+	for i := byte(0); i < 4; i++ {
+		code := create
+		if i%2 == 1 {
+			code = create2
+		}
+		alloc[common.Address{0: 0xc0, 19: i + 1}] = chain_config.GenesisAccount{Code: code}
+	}
+	test := new_hardfork_test(t, alloc, func(hf *chain_config.HardforksConfig) { hf.RosaHf.BlockNum = 2 })
+	defer test.close()
+
+	size := func(n int) []byte { return common.LeftPadBytes(big.NewInt(int64(n)).Bytes(), 32) }
+	creation_gas := func(n int) uint64 { return vm.TxGasContractCreation + uint64(n)*vm.TxDataZeroGas }
+	call := func(i byte, n int) vm.Transaction { return test.trx(&common.Address{0: 0xc0, 19: i}, size(n), nil) }
+	pre_fork := test.block(
+		test.trx(nil, make([]byte, vm.MaxInitCodeSize+1), nil),
+		call(1, vm.MaxInitCodeSize),
+		call(2, vm.MaxInitCodeSize),
+	)
+	if res := pre_fork[0]; res.ConsensusErr != "" || res.ExecutionErr != "" || res.GasUsed != creation_gas(vm.MaxInitCodeSize+1) {
+		t.Fatalf("creation before the hardfork: %+v", res)
+	}
+	post_fork := test.block(
+		test.trx(nil, make([]byte, vm.MaxInitCodeSize+1), nil),
+		test.trx(nil, make([]byte, vm.MaxInitCodeSize), nil),
+		call(3, vm.MaxInitCodeSize),
+		call(4, vm.MaxInitCodeSize),
+		call(3, vm.MaxInitCodeSize+1),
+		call(4, vm.MaxInitCodeSize+1),
+	)
+	if res := post_fork[0]; res.ConsensusErr != util.NewErrorString(vm.ErrMaxInitCodeSizeExceeded) {
+		t.Fatalf("too large creation transaction: %+v", res)
+	}
+	word_gas := uint64(vm.MaxInitCodeSize/32) * vm.InitCodeWordGas
+	if res := post_fork[1]; res.ConsensusErr != "" || res.ExecutionErr != "" || res.GasUsed != creation_gas(vm.MaxInitCodeSize)+word_gas {
+		t.Fatalf("creation transaction: %+v", res)
+	}
+	for i := 0; i < 2; i++ {
+		if res := post_fork[2+i]; res.ExecutionErr != "" || res.GasUsed != pre_fork[1+i].GasUsed+word_gas {
+			t.Fatalf("create %d: gas used %d, before the hardfork %d", i, res.GasUsed, pre_fork[1+i].GasUsed)
+		}
+		// gas function errors are reported as out of gas
+		if res := post_fork[4+i]; res.ExecutionErr != util.NewErrorString(vm.ErrOutOfGas) || res.GasUsed != 1_000_000 {
+			t.Fatalf("too large create %d: %+v", i, res)
+		}
+	}
+}
+
+func TestEIP6780(t *testing.T) {
+	// SELFDESTRUCT to the caller and to the contract itself, the factory creates a contract which destructs itself in
+	// the constructor and stores its address to the slot 0
+	pre_fork_suicide, suicide, self_suicide, factory := common.Address{0: 0xc0, 19: 1}, common.Address{0: 0xc0, 19: 2}, common.Address{0: 0xc0, 19: 3}, common.Address{0: 0xc0, 19: 4}
+	alloc := chain_config.GenesisAlloc{
+		pre_fork_suicide: {Balance: big.NewInt(100), Code: common.FromHex("0x33ff")},
+		suicide:          {Balance: big.NewInt(100), Code: common.FromHex("0x33ff")},
+		self_suicide:     {Balance: big.NewInt(100), Code: common.FromHex("0x30ff")},
+		factory:          {Code: common.FromHex("0x6133ff6000526002601e6000f060005500")},
+	}
+	test := new_hardfork_test(t, alloc, func(hf *chain_config.HardforksConfig) { hf.RosaHf.BlockNum, hf.SalviaHf.BlockNum = 0, 2 })
+	defer test.close()
+	results := append(
+		test.block(test.trx(&pre_fork_suicide, nil, nil)),
+		test.block(test.trx(&suicide, nil, nil), test.trx(&self_suicide, nil, nil), test.trx(&factory, nil, nil))...)
+	for i, res := range results {
+		if res.ExecutionErr != "" || res.ConsensusErr != "" {
+			t.Fatalf("transaction %d: %+v", i, res)
+		}
+	}
+
+	reader := test.api.ReadBlock(2)
+	read_account := func(addr common.Address) (ret state_db.Account) {
+		reader.GetAccount(&addr, func(acc state_db.Account) { ret = acc })
+		return
+	}
+	if acc := read_account(pre_fork_suicide); acc.Balance != nil {
+		t.Fatalf("contract destructed before the hardfork exists: %+v", acc)
+	}
+	if acc := read_account(suicide); acc.Balance == nil || acc.Balance.Sign() != 0 || acc.CodeSize != 2 {
+		t.Fatalf("contract destructed after the hardfork: %+v", acc)
+	}
+	if acc := read_account(test.sender); acc.Balance == nil || acc.Balance.Cmp(big.NewInt(200)) != 0 {
+		t.Fatalf("beneficiary: %+v", acc)
+	}
+	if acc := read_account(self_suicide); acc.Balance == nil || acc.Balance.Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("balance of the contract destructed to itself: %+v", acc)
+	}
+	var created common.Address
+	reader.GetAccountStorage(&factory, &common.Hash{}, func(v []byte) { created = common.BytesToAddress(v) })
+	if created == (common.Address{}) {
+		t.Fatal("contract is not created")
+	}
+	if acc := read_account(created); acc.Balance != nil {
+		t.Fatalf("contract destructed in the creating transaction exists: %+v", acc)
+	}
+
+	// suicide is traced under both semantics
+	for blk_n, addr := range map[types.BlockNum]common.Address{1: pre_fork_suicide, 3: self_suicide} {
+		trxs := []vm.Transaction{test.trx(&addr, nil, nil)}
+		blk := &vm.Block{Number: blk_n, BlockInfo: vm.BlockInfo{GasLimit: 100_000_000, Difficulty: big.NewInt(0)}}
+		var traces []vm.TraceCallResult
+		if err := json.Unmarshal(test.api.Trace(blk, &[]vm.Transaction{}, &trxs, &vm.TracingConfig{Trace: true}), &traces); err != nil {
+			t.Fatal(err)
+		}
+		if len(traces) != 1 || len(traces[0].Trace) != 2 || traces[0].Trace[0].Subtraces != 1 || traces[0].Trace[1].Type != vm.SUICIDE {
+			t.Fatalf("block %d: traces %+v", blk_n, traces)
+		}
+		action := traces[0].Trace[1].Action.(map[string]any)
+		if common.HexToAddress(action["address"].(string)) != addr || action["balance"] != "0x64" {
+			t.Fatalf("block %d: suicide trace action %v", blk_n, action)
+		}
+	}
+}
+
+func TestEIP7702(t *testing.T) {
+	authority_key := common.LeftPadBytes([]byte{0x7e, 0x57}, 32)
+	x, y := secp256k1.S256().ScalarBaseMult(authority_key)
+	authority := common.BytesToAddress(keccak256.Hash(common.LeftPadBytes(x.Bytes(), 32), common.LeftPadBytes(y.Bytes(), 32))[12:])
+	authorize := func(chain_id int64, addr common.Address, nonce uint64) vm.SetCodeAuthorization {
+		auth := vm.SetCodeAuthorization{ChainID: big.NewInt(chain_id), Address: addr, Nonce: nonce}
+		sig, err := secp256k1.Sign(auth.SigHash()[:], authority_key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		auth.R, auth.S, auth.V = new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), sig[64]
+		return auth
+	}
+	// the delegate stores the caller and the executing address to the slots 0 and 1, the caller calls the address
+	// from the calldata and stores its code size to the slot 0
+	delegate, caller := common.Address{0: 0xc0, 19: 1}, common.Address{0: 0xc0, 19: 2}
+	alloc := chain_config.GenesisAlloc{
+		delegate: {Code: common.FromHex("0x336000553060015500")},
+		caller:   {Code: common.FromHex("0x600060006000600060006000355af1506000353b60005500")},
+	}
+	test := new_hardfork_test(t, alloc, func(hf *chain_config.HardforksConfig) {
+		hf.RosaHf.BlockNum, hf.SalviaHf.BlockNum, hf.TiliaHf.BlockNum = 0, 0, 2
+	})
+	defer test.close()
+	set_code_trx := func(to common.Address, auths ...vm.SetCodeAuthorization) vm.Transaction {
+		trx := test.trx(&to, nil, nil)
+		trx.AuthorizationList = auths
+		return trx
+	}
+	reader := func(blk_n types.BlockNum) (ret struct {
+		code    func(common.Address) []byte
+		storage func(common.Address, byte) common.Hash
+		nonce   func(common.Address) uint64
+	}) {
+		r := test.api.ReadBlock(blk_n)
+		ret.code = func(addr common.Address) []byte { return r.GetCodeByAddress(&addr) }
+		ret.storage = func(addr common.Address, key byte) (val common.Hash) {
+			r.GetAccountStorage(&addr, &common.Hash{31: key}, func(v []byte) { val = common.BytesToHash(v) })
+			return
+		}
+		ret.nonce = func(addr common.Address) (nonce uint64) {
+			r.GetAccount(&addr, func(acc state_db.Account) { nonce = acc.Nonce.Uint64() })
+			return
+		}
+		return
+	}
+
+	// authorizations are ignored before the hardfork
+	if res := test.block(set_code_trx(authority, authorize(0, delegate, 0)))[0]; res.GasUsed != vm.TxGas || res.ConsensusErr != "" {
+		t.Fatalf("set code transaction before the hardfork: %+v", res)
+	}
+	if code := reader(1).code(authority); len(code) != 0 {
+		t.Fatalf("code set before the hardfork %x", code)
+	}
+
+	results := test.block(
+		// the authorization for the other chain is skipped
+		set_code_trx(authority, authorize(1, caller, 0), authorize(0, delegate, 0)),
+		test.trx(&caller, common.LeftPadBytes(authority[:], 32), nil),
+	)
+	for i, res := range results {
+		if res.ExecutionErr != "" || res.ConsensusErr != "" {
+			t.Fatalf("transaction %d: %+v", i, res)
+		}
+	}
+	if results[0].GasUsed < vm.TxGas+2*vm.TxAuthTupleGas {
+		t.Fatalf("set code transaction: gas used %d", results[0].GasUsed)
+	}
+	r := reader(2)
+	if code := r.code(authority); !bytes.Equal(code, vm.AddressToDelegation(&delegate)) {
+		t.Fatalf("authority code %x", code)
+	}
+	if nonce := r.nonce(authority); nonce != 1 {
+		t.Fatalf("authority nonce %d", nonce)
+	}
+	// the delegated code is executed in the context of the authority, EXTCODESIZE returns the designator size
+	if r.storage(authority, 0) != common.BytesToHash(caller[:]) || r.storage(authority, 1) != common.BytesToHash(authority[:]) {
+		t.Fatalf("authority storage %x %x", r.storage(authority, 0), r.storage(authority, 1))
+	}
+	if r.storage(delegate, 0) != (common.Hash{}) {
+		t.Fatal("delegate storage is modified")
+	}
+	if size := r.storage(caller, 0); size != (common.Hash{31: 23}) {
+		t.Fatalf("EXTCODESIZE %x", size)
+	}
+
+	creation := test.trx(nil, nil, nil)
+	creation.AuthorizationList = []vm.SetCodeAuthorization{authorize(0, delegate, 1)}
+	if res := test.block(creation)[0]; res.ConsensusErr != util.NewErrorString(vm.ErrSetCodeTxCreate) {
+		t.Fatalf("set code creation transaction: %+v", res)
+	}
+
+	// the authorization of an existing account is refunded, the zero address clears the code
+	res := test.block(set_code_trx(authority, authorize(0, common.Address{}, 1)))[0]
+	if used := vm.TxGas + vm.TxAuthTupleGas; res.GasUsed != used-min(vm.TxAuthTupleGas-vm.TxAuthTupleBaseGas, used/vm.RefundQuotientEIP3529) {
+		t.Fatalf("delegation reset: gas used %d", res.GasUsed)
+	}
+	if r := reader(4); len(r.code(authority)) != 0 || r.nonce(authority) != 2 {
+		t.Fatalf("authority code %x, nonce %d", r.code(authority), r.nonce(authority))
+	}
+}
+
+func TestBaseFeeSink(t *testing.T) {
+	sender, sink, to := common.HexToAddress("0x7e57"), common.HexToAddress("0x5127"), common.HexToAddress("0xc0")
+	alloc := chain_config.GenesisAlloc{sender: {Balance: big.NewInt(1e18)}}
+	test := new_hardfork_test(t, alloc, func(hf *chain_config.HardforksConfig) { hf.LantanaHf.BaseFeeSink = sink })
+	defer test.close()
+
+	paid := test.trx(&to, nil, nil)
+	paid.GasPrice = big.NewInt(15)
+	system := test.trx(&to, nil, nil)
+	system.From = common.ZeroAddress
+	st := test.api.GetStateTransition()
+	st.BeginBlock(&vm.BlockInfo{GasLimit: 100_000_000, Difficulty: big.NewInt(0), BaseFee: big.NewInt(10)})
+	for _, trx := range []vm.Transaction{paid, system} {
+		if res := st.ExecuteTransaction(&trx); res.GasUsed != vm.TxGas || res.ConsensusErr != "" {
+			t.Fatalf("transaction %+v: %+v", trx, res)
+		}
+	}
+	st.EndBlock()
+	st.Commit()
+
+	// only the base fee of the transaction which paid for the gas is collected
+	var balance *big.Int
+	test.api.ReadBlock(1).GetAccount(&sink, func(acc state_db.Account) { balance = acc.Balance })
+	if expected := big.NewInt(10 * int64(vm.TxGas)); balance == nil || balance.Cmp(expected) != 0 {
+		t.Fatalf("sink balance %v, expected %v", balance, expected)
+	}
+}
+
+func TestBaseFeeMagnolia(t *testing.T) {
+	sender, author, to := common.HexToAddress("0x7e57"), common.HexToAddress("0xa0"), common.HexToAddress("0xc0")
+	alloc := chain_config.GenesisAlloc{sender: {Balance: big.NewInt(1e18)}}
+	test := new_hardfork_test(t, alloc, func(hf *chain_config.HardforksConfig) { hf.LantanaHf.BlockNum = 2 })
+	defer test.close()
+	balance := func(blk_n types.BlockNum, addr common.Address) (ret *big.Int) {
+		ret = big.NewInt(0)
+		test.api.ReadBlock(blk_n).GetAccount(&addr, func(acc state_db.Account) { ret = acc.Balance })
+		return
+	}
+	execute := func(base_fee *big.Int) vm.ExecutionResult {
+		trx := test.trx(&to, nil, nil)
+		trx.GasPrice = big.NewInt(15)
+		st := test.api.GetStateTransition()
+		st.BeginBlock(&vm.BlockInfo{Author: author, GasLimit: 100_000_000, Difficulty: big.NewInt(0), BaseFee: base_fee})
+		res := st.ExecuteTransaction(&trx)
+		if res.GasUsed != vm.TxGas || res.ConsensusErr != "" {
+			t.Fatalf("transaction: %+v", res)
+		}
+		st.EndBlock()
+		st.Commit()
+		return res
+	}
+
+	// the whole price is the tip before Lantana, it is not lost in the encoding
+	res := execute(nil)
+	var decoded vm.ExecutionResult
+	rlp.MustDecodeBytes(rlp.MustEncodeToBytes(&res), &decoded)
+	if decoded.EffectiveTip == nil || decoded.EffectiveTip.Cmp(big.NewInt(15)) != 0 {
+		t.Fatalf("tip before Lantana %v", decoded.EffectiveTip)
+	}
+
+	// the fees rewards are distributed by the client from the tips, the base fee is burned
+	if res := execute(big.NewInt(10)); res.EffectiveTip == nil || res.EffectiveTip.Cmp(big.NewInt(5)) != 0 {
+		t.Fatalf("tip since Lantana %v", res.EffectiveTip)
+	}
+	if paid := new(big.Int).Sub(balance(1, sender), balance(2, sender)); paid.Cmp(big.NewInt(15*int64(vm.TxGas))) != 0 {
+		t.Fatalf("sender paid %v", paid)
+	}
+	if balance(2, author).Sign() != 0 {
+		t.Fatalf("author balance %v", balance(2, author))
+	}
+}
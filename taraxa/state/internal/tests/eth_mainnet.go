@@ -100,6 +100,7 @@ func main() {
 		GasLimit   hexutil.Uint64 `json:"gasLimit"  gencodec:"required"`
 		Time       hexutil.Uint64 `json:"timestamp"  gencodec:"required"`
 		Difficulty *hexutil.Big   `json:"difficulty"  gencodec:"required"`
+		BaseFee    *hexutil.Big   `json:"baseFeePerGas"`
 	}
 	type BlockInfo struct {
 		VmBlock
@@ -1,6 +1,7 @@
 package state_db
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/Taraxa-project/taraxa-evm/common"
@@ -27,6 +28,13 @@ type DB interface {
 
 type ErrFutureBlock util.ErrorString
 
+// ErrStatePruned is raised by the reads of the blocks which are out of the kept history
+type ErrStatePruned util.ErrorString
+
+var ErrRevertToFutureBlock = errors.New("can't revert to a block which is not committed")
+var ErrStateRootUnknown = errors.New("state root of the block is not stored")
+var ErrRevertToPrunedBlock = errors.New("can't revert to a block which is pruned")
+
 func GetBlockStateReader(db DB, blk_n types.BlockNum) ExtendedReader {
 	last_committed_blk_n := db.GetLatestState().GetCommittedDescriptor().BlockNum
 	if last_committed_blk_n < blk_n {
@@ -38,7 +46,10 @@ func GetBlockStateReader(db DB, blk_n types.BlockNum) ExtendedReader {
 type LatestState interface {
 	GetCommittedDescriptor() StateDescriptor
 	BeginPendingBlock() PendingBlockState
+	// Commit may write the block asynchronously, the committed block is readable right away
 	Commit(state_root common.Hash) error
+	// Barrier waits until the committed blocks are written and returns the last written one
+	Barrier() (StateDescriptor, error)
 }
 type Reader interface {
 	Get(Column, *common.Hash, func([]byte))
@@ -52,6 +63,16 @@ type PendingBlockState interface {
 	GetNumber() types.BlockNum
 }
 
+// PreimageWriter is implemented by the pending blocks of the databases which store the preimages of the trie keys
+type PreimageWriter interface {
+	PutPreimage(hash *common.Hash, preimage []byte)
+}
+
+// PreimageReader looks up the preimages of the trie keys, nil means that the preimage is unknown
+type PreimageReader interface {
+	GetPreimage(hash *common.Hash) []byte
+}
+
 type StateDescriptor struct {
 	BlockNum  types.BlockNum
 	StateRoot common.Hash
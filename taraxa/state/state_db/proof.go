@@ -0,0 +1,113 @@
+package state_db
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/crypto"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_common"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/trie"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/bigutil"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+)
+
+var ErrAccountProofMismatch = errors.New("account doesn't match the proof")
+var ErrStorageProofMismatch = errors.New("storage value doesn't match the proof")
+
+type StorageProof struct {
+	Key   common.Hash
+	Value *big.Int
+	Proof [][]byte
+}
+
+// AccountProof is the proof of the account and its storage slots (EIP-1186). Proofs are lists of RLP encoded
+// trie nodes from the root to the key
+type AccountProof struct {
+	Address      common.Address
+	Balance      *big.Int
+	Nonce        *big.Int
+	CodeHash     common.Hash
+	StorageHash  common.Hash
+	AccountProof [][]byte
+	StorageProof []StorageProof
+}
+
+// GetProof makes the proof of the account and the storage keys against the state root
+func (self ExtendedReader) GetProof(state_root *common.Hash, addr *common.Address, keys []common.Hash) (ret AccountProof) {
+	ret.Address = *addr
+	ret.Balance, ret.Nonce = big.NewInt(0), big.NewInt(0)
+	ret.CodeHash, ret.StorageHash = crypto.EmptyBytesKeccak256, state_common.EmptyRLPListHash
+	var value trie.Value
+	if !state_common.IsEmptyStateRoot(state_root) {
+		ret.AccountProof, value = trie.Reader{MainTrieSchema{}}.Prove(MainTrieInputAdapter{self}, state_root, keccak256.Hash(addr[:]))
+	}
+	var acc Account
+	if value != nil {
+		enc_storage, _ := value.EncodeForTrie()
+		acc = DecodeAccountFromTrie(enc_storage)
+		ret.Balance, ret.Nonce = acc.Balance, acc.Nonce
+		if acc.CodeHash != nil {
+			ret.CodeHash = *acc.CodeHash
+		}
+		if acc.StorageRootHash != nil {
+			ret.StorageHash = *acc.StorageRootHash
+		}
+	}
+	ret.StorageProof = make([]StorageProof, len(keys))
+	for i := range keys {
+		storage_proof := &ret.StorageProof[i]
+		storage_proof.Key, storage_proof.Value = keys[i], big.NewInt(0)
+		if acc.StorageRootHash == nil {
+			continue
+		}
+		var value trie.Value
+		storage_proof.Proof, value = trie.Reader{AccountTrieSchema{}}.Prove(
+			AccountTrieInputAdapter{addr, self},
+			acc.StorageRootHash,
+			keccak256.Hash(keys[i][:]))
+		if value != nil {
+			enc_storage, _ := value.EncodeForTrie()
+			storage_proof.Value = bigutil.FromBytes(enc_storage)
+		}
+	}
+	return
+}
+
+// VerifyProof checks that the account and the storage values match the proofs made against the state root
+func (self *AccountProof) VerifyProof(state_root *common.Hash) error {
+	acc_enc, err := trie.VerifyProof(state_root, keccak256.Hash(self.Address[:]), self.AccountProof)
+	if err != nil {
+		return err
+	}
+	acc := Account{Nonce: self.Nonce, Balance: self.Balance, StorageRootHash: &self.StorageHash, CodeHash: &self.CodeHash}
+	if _, enc_hash := acc.EncodeForTrie(); acc_enc != nil && !bytes.Equal(acc_enc, enc_hash) {
+		return ErrAccountProofMismatch
+	}
+	if acc_enc == nil && !acc.isEmpty() {
+		return ErrAccountProofMismatch
+	}
+	for i := range self.StorageProof {
+		storage_proof := &self.StorageProof[i]
+		value_enc, err := trie.VerifyProof(&self.StorageHash, keccak256.Hash(storage_proof.Key[:]), storage_proof.Proof)
+		if err != nil {
+			return err
+		}
+		var expected []byte
+		if storage_proof.Value.Sign() != 0 {
+			expected = rlp.ToRLPStringSimple(storage_proof.Value.Bytes())
+		}
+		if !bytes.Equal(value_enc, expected) {
+			return ErrStorageProofMismatch
+		}
+	}
+	return nil
+}
+
+// isEmpty tells if the account is the one returned in the proof of an absent account
+func (self *Account) isEmpty() bool {
+	return self.Nonce.Sign() == 0 && self.Balance.Sign() == 0 &&
+		*self.CodeHash == crypto.EmptyBytesKeccak256 && *self.StorageRootHash == state_common.EmptyRLPListHash
+}
@@ -0,0 +1,122 @@
+package state_db
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_common"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/trie"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+)
+
+// AddressPreimages and KeyPreimages resolve the hashes which are the keys of the tries, nil means that the preimage
+// is unknown
+type AddressPreimages = func(addr_hash *common.Hash) *common.Address
+type KeyPreimages = func(key_hash *common.Hash) *common.Hash
+
+// KnownAddresses resolves the given addresses, the rest are looked up in the store if it is not nil
+func KnownAddresses(addrs []common.Address, store PreimageReader) AddressPreimages {
+	by_hash := make(map[common.Hash]*common.Address, len(addrs))
+	for i := range addrs {
+		by_hash[*keccak256.Hash(addrs[i][:])] = &addrs[i]
+	}
+	return func(addr_hash *common.Hash) *common.Address {
+		if addr := by_hash[*addr_hash]; addr != nil || store == nil {
+			return addr
+		}
+		if preimage := store.GetPreimage(addr_hash); len(preimage) == common.AddressLength {
+			return new(common.Address).SetBytes(preimage)
+		}
+		return nil
+	}
+}
+
+// KnownKeys resolves the given storage keys, the rest are looked up in the store if it is not nil
+func KnownKeys(keys []common.Hash, store PreimageReader) KeyPreimages {
+	by_hash := make(map[common.Hash]*common.Hash, len(keys))
+	for i := range keys {
+		by_hash[*keccak256.Hash(keys[i][:])] = &keys[i]
+	}
+	return func(key_hash *common.Hash) *common.Hash {
+		if key := by_hash[*key_hash]; key != nil || store == nil {
+			return key
+		}
+		if preimage := store.GetPreimage(key_hash); len(preimage) == common.HashLength {
+			return new(common.Hash).SetBytes(preimage)
+		}
+		return nil
+	}
+}
+
+type AccountRangeEntry struct {
+	AddrHash common.Hash
+	Account  Account
+	// Set if the address is known
+	Address *common.Address `rlp:"nil"`
+}
+
+type AccountRange struct {
+	Accounts []AccountRangeEntry
+	// Hash to continue from, nil if there are no more accounts
+	Next *common.Hash `rlp:"nil"`
+}
+
+type StorageRangeEntry struct {
+	KeyHash common.Hash
+	Value   []byte
+	// Set if the key is known
+	Key *common.Hash `rlp:"nil"`
+}
+
+type StorageRange struct {
+	Storage []StorageRangeEntry
+	// Hash to continue from, nil if there are no more keys
+	Next *common.Hash `rlp:"nil"`
+}
+
+// AccountRange reads up to limit accounts of the state starting from the address hash start, in the order of the
+// address hashes. preimages may be nil
+func (self ExtendedReader) AccountRange(state_root, start *common.Hash, limit uint64, preimages AddressPreimages) (ret AccountRange) {
+	if state_common.IsEmptyStateRoot(state_root) {
+		return
+	}
+	trie.Reader{MainTrieSchema{}}.ForEachFrom(MainTrieInputAdapter{self}, state_root, start, true,
+		func(addr_hash *common.Hash, val trie.Value) bool {
+			if uint64(len(ret.Accounts)) == limit {
+				ret.Next = new(common.Hash).SetBytes(addr_hash[:])
+				return false
+			}
+			enc_storage, _ := val.EncodeForTrie()
+			entry := AccountRangeEntry{AddrHash: *addr_hash, Account: DecodeAccountFromTrie(enc_storage)}
+			if preimages != nil {
+				entry.Address = preimages(addr_hash)
+			}
+			ret.Accounts = append(ret.Accounts, entry)
+			return true
+		})
+	return
+}
+
+// StorageRange reads up to limit storage values of the account starting from the key hash start, in the order of the
+// key hashes. preimages may be nil
+func (self ExtendedReader) StorageRange(addr *common.Address, start *common.Hash, limit uint64, preimages KeyPreimages) (ret StorageRange) {
+	self.GetRawAccount(addr, func(acc []byte) {
+		storage_root := StorageRoot(acc)
+		if storage_root == nil {
+			return
+		}
+		trie.Reader{AccountTrieSchema{}}.ForEachFrom(AccountTrieInputAdapter{addr, self}, storage_root, start, true,
+			func(key_hash *common.Hash, val trie.Value) bool {
+				if uint64(len(ret.Storage)) == limit {
+					ret.Next = new(common.Hash).SetBytes(key_hash[:])
+					return false
+				}
+				enc_storage, _ := val.EncodeForTrie()
+				entry := StorageRangeEntry{KeyHash: *key_hash, Value: common.CopyBytes(enc_storage)}
+				if preimages != nil {
+					entry.Key = preimages(key_hash)
+				}
+				ret.Storage = append(ret.Storage, entry)
+				return true
+			})
+	})
+	return
+}
@@ -0,0 +1,149 @@
+package state_db_memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+)
+
+// DB keeps the state in memory. Trie values are versioned the same way as in the rocksdb implementation,
+// so the state of every committed block can be read
+type DB struct {
+	values         [state_db.COL_COUNT]map[common.Hash][]byte
+	versioned      [state_db.COL_COUNT]map[common.Hash][]version
+	state_roots    map[types.BlockNum]common.Hash
+	config_changes map[uint64][]byte
+	mu             sync.RWMutex
+	latest_state   LatestState
+}
+
+type version struct {
+	blk_n types.BlockNum
+	value []byte
+}
+
+func is_versioned(col state_db.Column) bool {
+	return col == state_db.COL_main_trie_value || col == state_db.COL_acc_trie_value
+}
+
+func (self *DB) Init() *DB {
+	for col := state_db.Column(0); col < state_db.COL_COUNT; col++ {
+		if is_versioned(col) {
+			self.versioned[col] = make(map[common.Hash][]version)
+		} else {
+			self.values[col] = make(map[common.Hash][]byte)
+		}
+	}
+	self.state_roots = make(map[types.BlockNum]common.Hash)
+	self.config_changes = make(map[uint64][]byte)
+	self.latest_state.init(self)
+	return self
+}
+
+func (self *DB) Close() {}
+
+func (self *DB) GetBlockStateReader(blk_n types.BlockNum) state_db.Reader {
+	return block_state_reader{self, blk_n}
+}
+
+func (self *DB) GetLatestState() state_db.LatestState {
+	return &self.latest_state
+}
+
+// BeginFirstPendingBlock starts the first block of the empty database with the number blk_n
+func (self *DB) BeginFirstPendingBlock(blk_n types.BlockNum) state_db.PendingBlockState {
+	return self.latest_state.begin_first_pending_block(blk_n)
+}
+
+func (self *DB) GetStateRoot(blk_n types.BlockNum) *common.Hash {
+	defer util.LockUnlock(self.mu.RLocker())()
+	if root, present := self.state_roots[blk_n]; present {
+		return &root
+	}
+	return nil
+}
+
+func (self *DB) GetDPOSConfigChanges() map[uint64][]byte {
+	defer util.LockUnlock(self.mu.RLocker())()
+	res := make(map[uint64][]byte, len(self.config_changes))
+	for blk_n, cfg := range self.config_changes {
+		res[blk_n] = common.CopyBytes(cfg)
+	}
+	return res
+}
+
+func (self *DB) SaveDPOSConfigChange(blk uint64, cfg []byte) {
+	defer util.LockUnlock(&self.mu)()
+	self.config_changes[blk] = common.CopyBytes(cfg)
+}
+
+// RevertTo removes everything committed after blk_n and makes it the last committed block
+func (self *DB) RevertTo(blk_n types.BlockNum) error {
+	state_desc := self.latest_state.GetCommittedDescriptor()
+	if state_desc.BlockNum == types.BlockNumberNIL || state_desc.BlockNum < blk_n {
+		return state_db.ErrRevertToFutureBlock
+	}
+	if state_desc.BlockNum == blk_n {
+		return nil
+	}
+	state_root := self.GetStateRoot(blk_n)
+	if state_root == nil {
+		return state_db.ErrStateRootUnknown
+	}
+	util.Call(func() {
+		defer util.LockUnlock(&self.mu)()
+		for _, versions_by_key := range self.versioned {
+			for k, versions := range versions_by_key {
+				kept := sort.Search(len(versions), func(i int) bool { return versions[i].blk_n > blk_n })
+				if kept == 0 {
+					delete(versions_by_key, k)
+				} else {
+					versions_by_key[k] = versions[:kept]
+				}
+			}
+		}
+		for n := range self.state_roots {
+			if n > blk_n {
+				delete(self.state_roots, n)
+			}
+		}
+		for n := range self.config_changes {
+			if n > blk_n {
+				delete(self.config_changes, n)
+			}
+		}
+	})
+	self.latest_state.reset(state_db.StateDescriptor{BlockNum: blk_n, StateRoot: *state_root})
+	return nil
+}
+
+type block_state_reader struct {
+	*DB
+	blk_n types.BlockNum
+}
+
+func (self block_state_reader) Get(col state_db.Column, k *common.Hash, cb func([]byte)) {
+	if self.blk_n == types.BlockNumberNIL {
+		return
+	}
+	var v []byte
+	util.Call(func() {
+		defer util.LockUnlock(self.mu.RLocker())()
+		if !is_versioned(col) {
+			v = self.values[col][*k]
+			return
+		}
+		versions := self.versioned[col][*k]
+		// the latest version which is not newer than the block
+		if i := sort.Search(len(versions), func(i int) bool { return versions[i].blk_n > self.blk_n }); i != 0 {
+			v = versions[i-1].value
+		}
+	})
+	if len(v) != 0 {
+		cb(v)
+	}
+}
@@ -0,0 +1,79 @@
+package state_db_memory
+
+import (
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+)
+
+func get(reader state_db.Reader, col state_db.Column, k *common.Hash) (ret []byte) {
+	reader.Get(col, k, func(v []byte) { ret = common.CopyBytes(v) })
+	return
+}
+
+func TestVersionedReads(t *testing.T) {
+	db := new(DB).Init()
+	k_1, k_2 := common.Hash{1}, common.Hash{2}
+	commit := func(values map[common.Hash]byte) {
+		pending := db.GetLatestState().BeginPendingBlock()
+		for k, v := range values {
+			pending.Put(state_db.COL_main_trie_value, &k, []byte{v})
+			pending.Put(state_db.COL_code, &k, []byte{v})
+		}
+		db.GetLatestState().Commit(common.Hash{byte(pending.GetNumber())})
+	}
+	commit(map[common.Hash]byte{k_1: 1})
+	commit(map[common.Hash]byte{k_2: 2})
+	commit(map[common.Hash]byte{k_1: 3})
+
+	if desc := db.GetLatestState().GetCommittedDescriptor(); desc.BlockNum != 2 || desc.StateRoot != (common.Hash{2}) {
+		t.Fatalf("wrong committed descriptor %v", desc)
+	}
+	for blk_n, expected := range []struct{ v_1, v_2 []byte }{{[]byte{1}, nil}, {[]byte{1}, []byte{2}}, {[]byte{3}, []byte{2}}} {
+		reader := db.GetBlockStateReader(types.BlockNum(blk_n))
+		if v := get(reader, state_db.COL_main_trie_value, &k_1); string(v) != string(expected.v_1) {
+			t.Fatalf("block %d: wrong value %x of key 1", blk_n, v)
+		}
+		if v := get(reader, state_db.COL_main_trie_value, &k_2); string(v) != string(expected.v_2) {
+			t.Fatalf("block %d: wrong value %x of key 2", blk_n, v)
+		}
+		// not versioned columns keep only the latest value
+		if v := get(reader, state_db.COL_code, &k_1); string(v) != string([]byte{3}) {
+			t.Fatalf("block %d: wrong code %x", blk_n, v)
+		}
+	}
+	if v := get(db.GetBlockStateReader(types.BlockNumberNIL), state_db.COL_code, &k_1); v != nil {
+		t.Fatalf("nil block has value %x", v)
+	}
+
+	// pending block sees only the committed state
+	pending := db.GetLatestState().BeginPendingBlock()
+	pending.Put(state_db.COL_main_trie_value, &k_2, []byte{4})
+	if v := get(pending, state_db.COL_main_trie_value, &k_2); string(v) != string([]byte{2}) {
+		t.Fatalf("pending block sees not committed value %x", v)
+	}
+
+	if err := db.RevertTo(3); err != state_db.ErrRevertToFutureBlock {
+		t.Fatalf("revert to a future block: %v", err)
+	}
+	if err := db.RevertTo(0); err != nil {
+		t.Fatal(err)
+	}
+	if desc := db.GetLatestState().GetCommittedDescriptor(); desc.BlockNum != 0 || desc.StateRoot != (common.Hash{0}) {
+		t.Fatalf("wrong descriptor after revert %v", desc)
+	}
+	if v := get(db.GetBlockStateReader(2), state_db.COL_main_trie_value, &k_1); string(v) != string([]byte{1}) {
+		t.Fatalf("reverted value %x is visible", v)
+	}
+	// the pending block is dropped
+	commit(map[common.Hash]byte{k_1: 5})
+	reader := db.GetBlockStateReader(1)
+	if v := get(reader, state_db.COL_main_trie_value, &k_1); string(v) != string([]byte{5}) {
+		t.Fatalf("wrong value %x after revert", v)
+	}
+	if v := get(reader, state_db.COL_main_trie_value, &k_2); v != nil {
+		t.Fatalf("wrong value %x after revert", v)
+	}
+}
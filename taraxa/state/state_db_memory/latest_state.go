@@ -0,0 +1,107 @@
+package state_db_memory
+
+import (
+	"sync"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/asserts"
+)
+
+type LatestState struct {
+	*DB
+	// writes of the pending block, they become visible on commit
+	batch         [state_db.COL_COUNT]map[common.Hash][]byte
+	batch_mu      sync.Mutex
+	state_desc    state_db.StateDescriptor
+	pending_blk_n types.BlockNum
+	state_desc_mu sync.RWMutex
+}
+
+func (self *LatestState) init(db *DB) {
+	self.DB = db
+	self.clear_batch()
+	self.state_desc.BlockNum = types.BlockNumberNIL
+	self.pending_blk_n = self.state_desc.BlockNum
+}
+
+func (self *LatestState) clear_batch() {
+	for col := range self.batch {
+		self.batch[col] = make(map[common.Hash][]byte)
+	}
+}
+
+func (self *LatestState) GetCommittedDescriptor() (ret state_db.StateDescriptor) {
+	defer util.LockUnlock(self.state_desc_mu.RLocker())()
+	return self.state_desc
+}
+
+func (self *LatestState) BeginPendingBlock() state_db.PendingBlockState {
+	defer util.LockUnlock(&self.state_desc_mu)()
+	self.pending_blk_n++
+	return &PendingBlockState{block_state_reader{self.DB, self.state_desc.BlockNum}, self.pending_blk_n}
+}
+
+func (self *LatestState) begin_first_pending_block(blk_n types.BlockNum) state_db.PendingBlockState {
+	defer util.LockUnlock(&self.state_desc_mu)()
+	asserts.Holds(self.state_desc.BlockNum == types.BlockNumberNIL && self.pending_blk_n == types.BlockNumberNIL)
+	self.pending_blk_n = blk_n
+	return &PendingBlockState{block_state_reader{self.DB, self.state_desc.BlockNum}, blk_n}
+}
+
+func (self *LatestState) Commit(state_root common.Hash) error {
+	state_desc := state_db.StateDescriptor{BlockNum: self.pending_blk_n, StateRoot: state_root}
+	util.Call(func() {
+		defer util.LockUnlock(&self.batch_mu)()
+		defer util.LockUnlock(&self.mu)()
+		for col, writes := range self.batch {
+			for k, v := range writes {
+				if !is_versioned(state_db.Column(col)) {
+					self.values[col][k] = v
+					continue
+				}
+				versions := self.versioned[col][k]
+				// a version is written once, the same way as a key of the rocksdb column
+				if last := len(versions) - 1; last >= 0 && versions[last].blk_n == state_desc.BlockNum {
+					versions = versions[:last]
+				}
+				self.versioned[col][k] = append(versions, version{state_desc.BlockNum, v})
+			}
+		}
+		self.state_roots[state_desc.BlockNum] = state_root
+		self.clear_batch()
+	})
+	defer util.LockUnlock(&self.state_desc_mu)()
+	self.state_desc = state_desc
+	return nil
+}
+
+func (self *LatestState) Barrier() (state_db.StateDescriptor, error) {
+	return self.GetCommittedDescriptor(), nil
+}
+
+func (self *LatestState) reset(state_desc state_db.StateDescriptor) {
+	util.Call(func() {
+		defer util.LockUnlock(&self.batch_mu)()
+		self.clear_batch()
+	})
+	defer util.LockUnlock(&self.state_desc_mu)()
+	self.state_desc = state_desc
+	self.pending_blk_n = state_desc.BlockNum
+}
+
+type PendingBlockState struct {
+	block_state_reader
+	blk_n types.BlockNum
+}
+
+func (self *PendingBlockState) Put(col state_db.Column, k *common.Hash, v []byte) {
+	defer util.LockUnlock(&self.latest_state.batch_mu)()
+	self.latest_state.batch[col][*k] = common.CopyBytes(v)
+}
+
+func (self *PendingBlockState) GetNumber() types.BlockNum {
+	return self.blk_n
+}
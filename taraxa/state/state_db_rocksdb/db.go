@@ -2,11 +2,10 @@ package state_db_rocksdb
 
 import (
 	"bytes"
-	"encoding/binary"
 	"math/big"
 	"runtime"
 	"strconv"
-	"sync"
+	"sync/atomic"
 
 	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/core/types"
@@ -27,6 +26,8 @@ type DB struct {
 	versioned_read_pools      [col_COUNT]*util.Pool
 	latest_state              LatestState
 	maintenance_task_executor goroutines.GoroutineGroup
+	pruner                    pruner
+	history_start             atomic.Uint64
 	opts                      Opts
 	db_opts                   *grocksdb.Options
 }
@@ -35,6 +36,8 @@ const (
 	col_main_trie_value_latest = iota + state_db.COL_COUNT
 	col_acc_trie_value_latest
 	col_config_changes
+	col_prune_marks
+	col_preimages
 	col_COUNT
 )
 
@@ -48,6 +51,10 @@ var versioned_read_columns = []state_db.Column{state_db.COL_acc_trie_value, stat
 type Opts = struct {
 	Path                            string
 	DisableMostRecentTrieValueViews bool
+	// Number of the last blocks which state is kept, 0 means that the whole history is kept
+	HistoryDepth uint64 `rlp:"optional"`
+	// Preimages of the trie keys are not stored, the ones stored before are kept
+	DisablePreimages bool `rlp:"optional"`
 }
 
 func (self *DB) Init(opts Opts) *DB {
@@ -110,10 +117,16 @@ func (self *DB) Init(opts Opts) *DB {
 		})
 	}
 	self.latest_state.Init(self)
+	self.resume_revert()
+	self.init_history()
+	self.resume_prune()
 	return self
 }
 
 func (self *DB) Snapshot(dir string, log_size_for_flush uint64) error {
+	if _, err := self.latest_state.Barrier(); err != nil {
+		return err
+	}
 	c, err := self.db.NewCheckpoint()
 	if err != nil {
 		return err
@@ -121,191 +134,8 @@ func (self *DB) Snapshot(dir string, log_size_for_flush uint64) error {
 	return c.CreateCheckpoint(dir, log_size_for_flush)
 }
 
-func (self *DB) RecreateColumn(column state_db.Column, nodes map[common.Hash][]byte) {
-	range_limit := [common.VersionedKeyLength]byte{255}
-	range32 := grocksdb.Range{
-		Start: make([]byte, common.HashLength),
-		Limit: make([]byte, common.HashLength),
-	}
-	copy(range32.Limit, range_limit[0:common.HashLength])
-	err := self.db.DropColumnFamily(self.cf_handles[column])
-	if err != nil {
-		panic("Could not delete column")
-	}
-	self.cf_handles[column].Destroy()
-	acc_trie_handle, err2 := self.db.CreateColumnFamily(self.db_opts, strconv.Itoa(int(column+1)))
-	if err2 != nil {
-		panic("Could not create column")
-	}
-	self.cf_handles[column] = acc_trie_handle
-
-	for node_to_keep, b := range nodes {
-		self.db.PutCF(grocksdb.NewDefaultWriteOptions(), self.cf_handles[column], node_to_keep[:], b)
-	}
-	self.db.CompactRangeCF(self.cf_handles[column], range32)
-}
-
-func (self *DB) deleteStateValues(blk_num types.BlockNum) {
-	range_limit := [common.VersionedKeyLength]byte{255}
-	range40 := grocksdb.Range{
-		Start: make([]byte, common.VersionedKeyLength),
-		Limit: make([]byte, common.VersionedKeyLength),
-	}
-	copy(range40.Limit, range_limit[0:common.VersionedKeyLength])
-	prev_key := make([]byte, common.VersionedKeyLength)
-
-	//Select account storage values to prune
-	set_account_storage_value_to_prune := make([][]byte, 0)
-	itr := self.db.NewIteratorCF(self.opts_r_itr, self.cf_handles[state_db.COL_acc_trie_value])
-	itr.SeekToFirst()
-	for {
-		copy(prev_key, itr.Key().Data())
-		itr.Key().Free()
-		itr.Next()
-		if !itr.Valid() {
-			break
-		}
-		if bytes.Compare(prev_key[0:common.HashLength], itr.Key().Data()[0:common.HashLength]) == 0 {
-			ver_blk_num := binary.BigEndian.Uint64(itr.Key().Data()[common.HashLength:common.VersionedKeyLength])
-			if ver_blk_num < blk_num {
-				set_account_storage_value_to_prune = append(set_account_storage_value_to_prune, common.CopyBytes(prev_key))
-			}
-		}
-
-		if len(set_account_storage_value_to_prune) > db_prune_buffer_max_size {
-			for _, value_to_remove := range set_account_storage_value_to_prune {
-				self.db.DeleteCF(self.latest_state.opts_w, self.cf_handles[state_db.COL_acc_trie_value], value_to_remove)
-			}
-			set_account_storage_value_to_prune = make([][]byte, 0)
-		}
-	}
-	itr.Close()
-
-	for _, value_to_remove := range set_account_storage_value_to_prune {
-		self.db.DeleteCF(self.latest_state.opts_w, self.cf_handles[state_db.COL_acc_trie_value], value_to_remove)
-	}
-	self.db.CompactRangeCF(self.cf_handles[state_db.COL_acc_trie_value], range40)
-}
-
-func (self *DB) recreateMainTrie(state_root_to_keep *[]common.Hash, blk_num types.BlockNum) {
-	current_block_state := state_db.GetBlockStateReader(self, blk_num)
-	//Select nodes which are not to be deleted
-	nodes_to_keep := make(map[common.Hash][]byte)
-	for _, root_to_keep := range *state_root_to_keep {
-		current_block_state.ForEachMainNodeHashByRoot(&root_to_keep, func(h *common.Hash, b []byte) {
-			nodes_to_keep[*h] = common.CopyBytes(b)
-		})
-	}
-	self.RecreateColumn(state_db.COL_main_trie_node, nodes_to_keep)
-}
-
-func (self *DB) deleteStateRoot(blk_num types.BlockNum) {
-	range_limit := [common.VersionedKeyLength]byte{255}
-	range40 := grocksdb.Range{
-		Start: make([]byte, common.VersionedKeyLength),
-		Limit: make([]byte, common.VersionedKeyLength),
-	}
-	copy(range40.Limit, range_limit[0:common.VersionedKeyLength])
-
-	//Select main trie values to prune/remove
-	set_value_to_prune := make([][]byte, 0)
-	set_storage_root_to_keep := make([]common.Hash, 0)
-	current_block_state := state_db.GetBlockStateReader(self, blk_num)
-
-	//Iterate over all values and select which to keep
-	itr := self.db.NewIteratorCF(self.opts_r_itr, self.cf_handles[state_db.COL_main_trie_value])
-	itr.SeekToFirst()
-	prev_key := make([]byte, common.VersionedKeyLength)
-	for {
-		copy(prev_key, itr.Key().Data())
-		itr.Key().Free()
-		account := state_db.DecodeAccountFromTrie(itr.Value().Data())
-		itr.Value().Free()
-		itr.Next()
-		if !itr.Valid() {
-			if account.StorageRootHash != nil {
-				set_storage_root_to_keep = append(set_storage_root_to_keep, *account.StorageRootHash)
-			}
-			break
-		}
-		if bytes.Compare(prev_key[0:common.HashLength], itr.Key().Data()[0:common.HashLength]) == 0 {
-			//Only prune the previous value if current value for the same account is below blk_num
-			ver_blk_num := binary.BigEndian.Uint64(itr.Key().Data()[common.HashLength:common.VersionedKeyLength])
-			if ver_blk_num < blk_num {
-				set_value_to_prune = append(set_value_to_prune, common.CopyBytes(prev_key))
-			} else {
-				if account.StorageRootHash != nil {
-					set_storage_root_to_keep = append(set_storage_root_to_keep, *account.StorageRootHash)
-				}
-			}
-		} else {
-			if account.StorageRootHash != nil {
-				set_storage_root_to_keep = append(set_storage_root_to_keep, *account.StorageRootHash)
-			}
-		}
-
-		if len(set_value_to_prune) > db_prune_buffer_max_size {
-			for _, value_to_remove := range set_value_to_prune {
-				self.db.DeleteCF(self.latest_state.opts_w, self.cf_handles[state_db.COL_main_trie_value], value_to_remove)
-			}
-			set_value_to_prune = make([][]byte, 0)
-		}
-	}
-	itr.Close()
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for _, value_to_remove := range set_value_to_prune {
-			self.db.DeleteCF(self.latest_state.opts_w, self.cf_handles[state_db.COL_main_trie_value], value_to_remove)
-		}
-		self.db.CompactRangeCF(self.cf_handles[state_db.COL_main_trie_value], range40)
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		//Select account nodes to prune
-		account_nodes_to_keep := make(map[common.Hash][]byte)
-		for _, root_to_keep := range set_storage_root_to_keep {
-			current_block_state.ForEachAccountNodeHashByRoot(&root_to_keep, func(h *common.Hash, b []byte) {
-				account_nodes_to_keep[*h] = common.CopyBytes(b)
-			})
-		}
-		self.RecreateColumn(state_db.COL_acc_trie_node, account_nodes_to_keep)
-	}()
-	wg.Wait()
-}
-
-func (self *DB) Prune(state_root_to_keep []common.Hash, blk_num types.BlockNum) {
-	var wg sync.WaitGroup
-
-	// Asynchronously delete state values
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		self.deleteStateValues(blk_num)
-	}()
-
-	// Asynchronously recreate Main trie
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		self.recreateMainTrie(&state_root_to_keep, blk_num)
-	}()
-
-	// Asynchronously delete state root and main trie values
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		self.deleteStateRoot(blk_num)
-	}()
-
-	wg.Wait()
-}
-
 func (self *DB) Close() {
+	self.close_pruner()
 	self.latest_state.Close()
 	self.invalidate_versioned_read_pools()
 	self.maintenance_task_executor.JoinAndClose()
@@ -331,6 +161,13 @@ func (self block_state_reader) Get(col state_db.Column, k *common.Hash, cb func(
 	if self.blk_n == types.BlockNumberNIL {
 		return
 	}
+	self.check_not_pruned(self.blk_n)
+	if v, ok := self.latest_state.get_unwritten(col, k, self.blk_n); ok {
+		if len(v) != 0 {
+			cb(v)
+		}
+		return
+	}
 	if versioned_read_pool := self.versioned_read_pools[col]; versioned_read_pool != nil {
 		pool_handle := versioned_read_pool.Get()
 		defer versioned_read_pool.Return(pool_handle)
@@ -367,6 +204,11 @@ func (self *DB) GetLatestState() state_db.LatestState {
 	return &self.latest_state
 }
 
+// BeginFirstPendingBlock starts the first block of the empty database with the number blk_n
+func (self *DB) BeginFirstPendingBlock(blk_n types.BlockNum) state_db.PendingBlockState {
+	return self.latest_state.begin_first_pending_block(blk_n)
+}
+
 func (self *DB) GetDPOSConfigChanges() map[uint64][]byte {
 	res := make(map[uint64][]byte)
 
@@ -0,0 +1,220 @@
+package state_db_rocksdb
+
+import (
+	"bytes"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/crypto"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_common"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/trie"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+	"github.com/linxGnu/grocksdb"
+)
+
+// Fsck checks the state of a block: the main trie and the account tries are walked from the state root, the hashes
+// of the nodes are recomputed and the code is checked against its hash. The latest views of the trie values are
+// checked against the newest versions. Blocks may be committed meanwhile. The values of an account trie are stored by
+// the address, so the accounts with storage which address is not resolved are reported as issues
+
+type FsckIssueKind = uint8
+
+const (
+	FsckMissingNode FsckIssueKind = iota
+	FsckCorruptedNode
+	FsckMissingValue
+	FsckMissingCode
+	FsckCorruptedCode
+	FsckLatestViewMismatch
+	// Only the nodes of the account trie are verified, Key is the storage root
+	FsckUnresolvedAddress
+)
+
+var fsck_issue_kinds = map[trie.VerifyIssueKind]FsckIssueKind{
+	trie.VerifyMissingNode:   FsckMissingNode,
+	trie.VerifyCorruptedNode: FsckCorruptedNode,
+	trie.VerifyMissingValue:  FsckMissingValue,
+}
+
+type FsckIssue struct {
+	Kind   FsckIssueKind
+	Column state_db.Column
+	// Node hash, key of the value in the trie, code hash or key of the latest view
+	Key common.Hash
+	// Hash of the address of the account which trie has the issue
+	AddrHash *common.Hash `rlp:"nil"`
+}
+
+type FsckOpts struct {
+	// Values of the account tries are verified only for the accounts which address is known, the rest are reported
+	// with FsckUnresolvedAddress
+	ResolveAddress    func(addr_hash *common.Hash) *common.Address
+	RepairLatestViews bool
+}
+
+type FsckReport struct {
+	StateDesc           state_db.StateDescriptor
+	NodesVerified       uint64
+	NodesNotVerified    uint64
+	Accounts            uint64
+	AccountsUnresolved  uint64
+	Codes               uint64
+	LatestViewsChecked  uint64
+	LatestViewsRepaired uint64
+	Issues              []FsckIssue
+}
+
+func (self *DB) Fsck(blk_n types.BlockNum, opts FsckOpts) (report FsckReport, err error) {
+	if _, err = self.latest_state.Barrier(); err != nil {
+		return
+	}
+	state_root := self.GetStateRoot(blk_n)
+	if state_root == nil {
+		return report, state_db.ErrStateRootUnknown
+	}
+	self.check_not_pruned(blk_n)
+	report.StateDesc = state_db.StateDescriptor{BlockNum: blk_n, StateRoot: *state_root}
+	if !state_common.IsEmptyStateRoot(state_root) {
+		self.fsck_tries(&report, opts)
+	}
+	if !self.opts.DisableMostRecentTrieValueViews {
+		self.fsck_latest_view(&report, state_db.COL_main_trie_value, col_main_trie_value_latest, opts.RepairLatestViews)
+		self.fsck_latest_view(&report, state_db.COL_acc_trie_value, col_acc_trie_value_latest, opts.RepairLatestViews)
+	}
+	return
+}
+
+func (self *DB) fsck_tries(report *FsckReport, opts FsckOpts) {
+	reader := state_db.GetBlockStateReader(self, report.StateDesc.BlockNum)
+	checked_codes := make(map[common.Hash]bool)
+	issue_reporter := func(node_col, value_col state_db.Column, addr_hash *common.Hash) func(trie.VerifyIssue) {
+		return func(issue trie.VerifyIssue) {
+			col := node_col
+			if issue.Kind == trie.VerifyMissingValue {
+				col = value_col
+			}
+			report.Issues = append(report.Issues, FsckIssue{fsck_issue_kinds[issue.Kind], col, issue.Key, addr_hash})
+		}
+	}
+	main_trie := trie.Verifier{Reader: trie.Reader{state_db.MainTrieSchema{}}, WithValues: true}
+	main_trie.OnIssue = issue_reporter(state_db.COL_main_trie_node, state_db.COL_main_trie_value, nil)
+	main_trie.OnValue = func(addr_hash *common.Hash, enc_storage []byte) {
+		report.Accounts++
+		acc := state_db.DecodeAccountFromTrie(enc_storage)
+		if code_hash := acc.CodeHash; code_hash != nil && *code_hash != crypto.EmptyBytesKeccak256 && !checked_codes[*code_hash] {
+			checked_codes[*code_hash] = true
+			report.Codes++
+			if code := reader.GetCode(code_hash); code == nil {
+				report.Issues = append(report.Issues, FsckIssue{Kind: FsckMissingCode, Column: state_db.COL_code, Key: *code_hash})
+			} else if *keccak256.Hash(code) != *code_hash {
+				report.Issues = append(report.Issues, FsckIssue{Kind: FsckCorruptedCode, Column: state_db.COL_code, Key: *code_hash})
+			}
+		}
+		if acc.StorageRootHash == nil {
+			return
+		}
+		addr_hash = new(common.Hash).SetBytes(addr_hash[:])
+		var addr *common.Address
+		if opts.ResolveAddress != nil {
+			addr = opts.ResolveAddress(addr_hash)
+		}
+		acc_trie := trie.Verifier{Reader: trie.Reader{state_db.AccountTrieSchema{}}, WithValues: addr != nil}
+		acc_trie.OnIssue = issue_reporter(state_db.COL_acc_trie_node, state_db.COL_acc_trie_value, addr_hash)
+		if addr == nil {
+			report.AccountsUnresolved++
+			report.Issues = append(report.Issues, FsckIssue{FsckUnresolvedAddress, state_db.COL_acc_trie_value, *acc.StorageRootHash, addr_hash})
+			addr = &common.Address{}
+		}
+		acc_trie.Verify(state_db.AccountTrieInputAdapter{addr, reader}, acc.StorageRootHash)
+		report.NodesVerified += acc_trie.NodesVerified
+		report.NodesNotVerified += acc_trie.NodesNotVerified
+	}
+	main_trie.Verify(state_db.MainTrieInputAdapter{reader}, &report.StateDesc.StateRoot)
+	report.NodesVerified += main_trie.NodesVerified
+	report.NodesNotVerified += main_trie.NodesNotVerified
+}
+
+// fsck_latest_view compares the latest view with the newest versions in a snapshot of the database. The mismatches
+// are checked again before the repair, as the blocks committed since the snapshot may fix them
+func (self *DB) fsck_latest_view(report *FsckReport, col, latest_col state_db.Column, repair bool) {
+	snapshot := self.db.NewSnapshot()
+	defer self.db.ReleaseSnapshot(snapshot)
+	opts_r := grocksdb.NewDefaultReadOptions()
+	defer opts_r.Destroy()
+	opts_r.SetSnapshot(snapshot)
+	opts_r.SetFillCache(false)
+	versions := self.db.NewIteratorCF(opts_r, self.cf_handles[col])
+	defer versions.Close()
+	latest := self.db.NewIteratorCF(opts_r, self.cf_handles[latest_col])
+	defer latest.Close()
+	var mismatches []common.Hash
+	versions.SeekToFirst()
+	latest.SeekToFirst()
+	for versions.Valid() || latest.Valid() {
+		var key common.Hash
+		var newest, actual []byte
+		cmp := 1
+		if versions.Valid() {
+			if !latest.Valid() {
+				cmp = -1
+			} else {
+				cmp = bytes.Compare(versions.Key().Data()[:common.HashLength], latest.Key().Data())
+			}
+		}
+		if cmp <= 0 {
+			key.SetBytes(versions.Key().Data()[:common.HashLength])
+			for ; versions.Valid() && bytes.HasPrefix(versions.Key().Data(), key[:]); versions.Next() {
+				newest = common.CopyBytes(versions.Value().Data())
+			}
+		}
+		if cmp >= 0 {
+			key.SetBytes(latest.Key().Data())
+			actual = common.CopyBytes(latest.Value().Data())
+			latest.Next()
+		}
+		report.LatestViewsChecked++
+		if !bytes.Equal(newest, actual) {
+			report.Issues = append(report.Issues, FsckIssue{Kind: FsckLatestViewMismatch, Column: latest_col, Key: key})
+			mismatches = append(mismatches, key)
+		}
+	}
+	util.PanicIfNotNil(versions.Err())
+	util.PanicIfNotNil(latest.Err())
+	if repair && len(mismatches) != 0 {
+		report.LatestViewsRepaired += self.repair_latest_view(col, latest_col, mismatches)
+	}
+}
+
+func (self *DB) repair_latest_view(col, latest_col state_db.Column, keys []common.Hash) (repaired uint64) {
+	defer util.LockUnlock(&self.pruner.write_mu)()
+	itr := self.db.NewIteratorCF(self.opts_r, self.cf_handles[col])
+	defer itr.Close()
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	for i := range keys {
+		key := keys[i][:]
+		var newest []byte
+		var versioned_key VersionedKey
+		versioned_key.SetKey(&keys[i])
+		versioned_key.SetVersion(types.BlockNumberNIL)
+		if itr.SeekForPrev(versioned_key[:]); itr.Valid() && bytes.HasPrefix(itr.Key().Data(), key) {
+			newest = itr.Value().Data()
+		}
+		actual, err := self.db.GetCF(self.opts_r, self.cf_handles[latest_col], key)
+		util.PanicIfNotNil(err)
+		if !bytes.Equal(newest, actual.Data()) {
+			if len(newest) != 0 {
+				batch.PutCF(self.cf_handles[latest_col], key, common.CopyBytes(newest))
+			} else {
+				batch.DeleteCF(self.cf_handles[latest_col], key)
+			}
+			repaired++
+		}
+		actual.Free()
+	}
+	util.PanicIfNotNil(itr.Err())
+	util.PanicIfNotNil(self.db.Write(self.latest_state.opts_w, batch))
+	return
+}
@@ -0,0 +1,83 @@
+package state_db_rocksdb
+
+import (
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/tests"
+)
+
+func TestFsck(t *testing.T) {
+	tc := tests.NewTestCtx(t)
+	defer tc.Close()
+	test := new_prune_test(t, tc.DataDir())
+	test.open()
+	defer test.close()
+	for i := 0; i < 5; i++ {
+		test.block(byte(i))
+	}
+	state_desc := test.api.GetCommittedStateDescriptor()
+	fsck := func(opts FsckOpts) FsckReport {
+		if opts.ResolveAddress == nil {
+			opts.ResolveAddress = state_db.KnownAddresses(nil, test.db)
+		}
+		report, err := test.db.Fsck(state_desc.BlockNum, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+	issue := func(report FsckReport, kind FsckIssueKind) *FsckIssue {
+		if len(report.Issues) != 1 || report.Issues[0].Kind != kind {
+			t.Fatalf("issues %v, expected one of kind %d", report.Issues, kind)
+		}
+		return &report.Issues[0]
+	}
+	if report := fsck(FsckOpts{}); len(report.Issues) != 0 || report.NodesVerified == 0 || report.Codes == 0 || report.LatestViewsChecked == 0 {
+		t.Fatalf("fsck of the correct state %+v", report)
+	}
+
+	// the storage of the accounts which address is unknown is not verified
+	report := fsck(FsckOpts{ResolveAddress: func(*common.Hash) *common.Address { return nil }})
+	if report.AccountsUnresolved == 0 || len(report.Issues) != int(report.AccountsUnresolved) {
+		t.Fatalf("unresolved addresses are not reported %+v", report)
+	}
+	for i := range report.Issues {
+		if report.Issues[i].Kind != FsckUnresolvedAddress || report.Issues[i].AddrHash == nil {
+			t.Fatalf("unexpected issue %+v", report.Issues[i])
+		}
+	}
+
+	// the latest view is repaired
+	var acc_hash common.Hash
+	itr := test.db.db.NewIteratorCF(test.db.opts_r_itr, test.db.cf_handles[col_main_trie_value_latest])
+	itr.SeekToFirst()
+	acc_hash.SetBytes(itr.Key().Data())
+	itr.Close()
+	test.db.db.PutCF(test.db.latest_state.opts_w, test.db.cf_handles[col_main_trie_value_latest], acc_hash[:], []byte{1})
+	if report := fsck(FsckOpts{RepairLatestViews: true}); issue(report, FsckLatestViewMismatch).Key != acc_hash || report.LatestViewsRepaired != 1 {
+		t.Fatalf("latest view is not repaired %+v", report)
+	}
+	if report := fsck(FsckOpts{}); len(report.Issues) != 0 {
+		t.Fatalf("issues %v after the repair", report.Issues)
+	}
+
+	var code_hash common.Hash
+	test.api.ReadBlock(state_desc.BlockNum).GetAccount(&test.contracts[0], func(acc state_db.Account) { code_hash = *acc.CodeHash })
+	code := test.api.ReadBlock(state_desc.BlockNum).GetCode(&code_hash)
+	test.db.db.DeleteCF(test.db.latest_state.opts_w, test.db.cf_handles[state_db.COL_code], code_hash[:])
+	if issue(fsck(FsckOpts{}), FsckMissingCode).Key != code_hash {
+		t.Fatal("missing code is not reported")
+	}
+	test.db.db.PutCF(test.db.latest_state.opts_w, test.db.cf_handles[state_db.COL_code], code_hash[:], code)
+
+	var node_hashes []common.Hash
+	test.api.ReadBlock(state_desc.BlockNum).ForEachMainNodeHashByRoot(&state_desc.StateRoot, func(h *common.Hash, _ []byte) {
+		node_hashes = append(node_hashes, *h)
+	})
+	test.db.db.DeleteCF(test.db.latest_state.opts_w, test.db.cf_handles[state_db.COL_main_trie_node], node_hashes[1][:])
+	if issue(fsck(FsckOpts{}), FsckMissingNode).Key != node_hashes[1] {
+		t.Fatal("missing node is not reported")
+	}
+}
@@ -0,0 +1,84 @@
+package state_db_rocksdb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+	"github.com/linxGnu/grocksdb"
+)
+
+// With Opts.HistoryDepth set only the state of the last HistoryDepth blocks is kept. Every HistoryDepth blocks
+// the state which is out of the window is pruned in the background, the reads of it panic with
+// state_db.ErrStatePruned. The window start is raised also by the explicit Prune
+
+var history_start_key = []byte("history_start")
+
+func (self *DB) init_history() {
+	v, err := self.db.Get(self.opts_r, history_start_key)
+	util.PanicIfNotNil(err)
+	defer v.Free()
+	if data := v.Data(); len(data) != 0 {
+		self.history_start.Store(binary.BigEndian.Uint64(data))
+	}
+	if state_desc := self.latest_state.GetCommittedDescriptor(); state_desc.BlockNum != types.BlockNumberNIL {
+		self.move_history_window(state_desc.BlockNum)
+	}
+}
+
+// HistoryStart returns the lowest block which state is readable
+func (self *DB) HistoryStart() types.BlockNum {
+	return self.history_start.Load()
+}
+
+func (self *DB) raise_history_start(blk_n types.BlockNum) {
+	for {
+		if start := self.history_start.Load(); blk_n <= start || self.history_start.CompareAndSwap(start, blk_n) {
+			return
+		}
+	}
+}
+
+func (self *DB) move_history_window(committed_blk_n types.BlockNum) {
+	if depth := self.opts.HistoryDepth; depth != 0 && depth <= committed_blk_n {
+		self.raise_history_start(committed_blk_n + 1 - depth)
+	}
+}
+
+func (self *DB) check_not_pruned(blk_n types.BlockNum) {
+	if start := self.history_start.Load(); blk_n < start {
+		panic(state_db.ErrStatePruned(fmt.Sprint("Requested blk num:", blk_n, ", history start:", start)))
+	}
+}
+
+// on_write schedules the prune of the blocks which are out of the window once the block is written
+func (self *DB) on_write(blk_n types.BlockNum) {
+	if depth := self.opts.HistoryDepth; depth != 0 && blk_n != 0 && blk_n%depth == 0 {
+		self.maintenance_task_executor.Submit(self.prune_history)
+	}
+}
+
+func (self *DB) prune_history() {
+	defer util.LockUnlock(&self.pruner.mu)()
+	if self.pruner.closed {
+		return
+	}
+	start, state_desc := self.HistoryStart(), self.latest_state.GetCommittedDescriptor()
+	var state_roots []common.Hash
+	for blk_n := start; blk_n <= state_desc.BlockNum; blk_n++ {
+		if state_root := self.GetStateRoot(blk_n); state_root != nil {
+			state_roots = append(state_roots, *state_root)
+		}
+	}
+	// the previous prune is not finished yet, the next one is scheduled in HistoryDepth blocks
+	if err := self.start_new_prune(state_roots, start); err != nil && err != ErrPruneInProgress {
+		panic(err)
+	}
+}
+
+func put_history_start(batch *grocksdb.WriteBatch, blk_n types.BlockNum) {
+	batch.Put(history_start_key, binary.BigEndian.AppendUint64(nil, blk_n))
+}
@@ -1,7 +1,9 @@
 package state_db_rocksdb
 
 import (
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util/asserts"
 
@@ -18,6 +20,9 @@ var last_committed_desc_key = []byte("last_committed_descriptor")
 
 var most_recent_trie_value_views_status_key = []byte("most_recent_trie_value_views_status")
 
+// Number of the committed blocks which may wait for the write, Commit waits when there are more of them
+const max_unwritten_blocks = 2
+
 type LatestState struct {
 	*DB
 	batch         *grocksdb.WriteBatch
@@ -26,6 +31,28 @@ type LatestState struct {
 	pending_blk_n types.BlockNum
 	state_desc_mu sync.RWMutex
 	opts_w        *grocksdb.WriteOptions
+	// Blocks are written in the background, until then they are read from memory
+	pending_writes *block_writes
+	unwritten      atomic.Pointer[[]*block_writes]
+	unwritten_cnt  chan struct{}
+	written_desc   state_db.StateDescriptor
+	write_err      error
+}
+
+// block_writes are the writes of a block by the column and the key, the newest version of the values
+type block_writes struct {
+	state_desc state_db.StateDescriptor
+	values     [state_db.COL_COUNT]map[common.Hash][]byte
+	preimages  map[common.Hash][]byte
+	mu         sync.Mutex
+}
+
+func new_block_writes(blk_n types.BlockNum) *block_writes {
+	ret := &block_writes{state_desc: state_db.StateDescriptor{BlockNum: blk_n}, preimages: make(map[common.Hash][]byte)}
+	for col := range ret.values {
+		ret.values[col] = make(map[common.Hash][]byte)
+	}
+	return ret
 }
 
 func (self *LatestState) Init(db *DB) *LatestState {
@@ -41,6 +68,9 @@ func (self *LatestState) Init(db *DB) *LatestState {
 		rlp.MustDecodeBytes(v, &self.state_desc)
 	}
 	self.pending_blk_n = self.state_desc.BlockNum
+	self.written_desc = self.state_desc
+	self.unwritten.Store(new([]*block_writes))
+	self.unwritten_cnt = make(chan struct{}, max_unwritten_blocks)
 	util.Call(func() {
 		s, err := self.db.Get(self.opts_r, most_recent_trie_value_views_status_key)
 		util.PanicIfNotNil(err)
@@ -82,15 +112,28 @@ func (self *LatestState) GetCommittedDescriptor() (ret state_db.StateDescriptor)
 func (self *LatestState) BeginPendingBlock() state_db.PendingBlockState {
 	defer util.LockUnlock(&self.state_desc_mu)()
 	self.pending_blk_n++
+	return self.begin_pending_block()
+}
+
+func (self *LatestState) begin_first_pending_block(blk_n types.BlockNum) state_db.PendingBlockState {
+	defer util.LockUnlock(&self.state_desc_mu)()
+	asserts.Holds(self.state_desc.BlockNum == types.BlockNumberNIL && self.pending_blk_n == types.BlockNumberNIL)
+	self.pending_blk_n = blk_n
+	return self.begin_pending_block()
+}
+
+func (self *LatestState) begin_pending_block() *PendingBlockState {
 	var keybuf VersionedKey
 	keybuf.SetVersion(self.pending_blk_n)
-	return &PendingBlockState{block_state_reader{self.DB, self.state_desc.BlockNum}, self.pending_blk_n, keybuf}
+	self.pending_writes = new_block_writes(self.pending_blk_n)
+	return &PendingBlockState{block_state_reader{self.DB, self.state_desc.BlockNum}, self.pending_blk_n, keybuf, self.pending_writes}
 }
 
 type PendingBlockState struct {
 	block_state_reader
 	blk_n              types.BlockNum
 	trie_value_key_buf VersionedKey
+	writes             *block_writes
 }
 
 func (self *PendingBlockState) Get(col state_db.Column, k *common.Hash, cb func([]byte)) {
@@ -105,9 +148,16 @@ func (self *PendingBlockState) Get(col state_db.Column, k *common.Hash, cb func(
 }
 
 func (self *PendingBlockState) Put(col state_db.Column, k *common.Hash, v []byte) {
+	// the order of the writes of a key is the same in memory and in the batch
+	defer util.LockUnlock(&self.writes.mu)()
+	self.writes.values[col][*k] = v
 	self.latest_state.writer_thread.Submit(func() {
 		if col != state_db.COL_acc_trie_value && col != state_db.COL_main_trie_value {
 			self.latest_state.batch.PutCF(self.cf_handles[col], k[:], v)
+			// nodes written during the prune are not deleted by it
+			if (col == state_db.COL_main_trie_node || col == state_db.COL_acc_trie_node) && self.pruner.barrier.Load() {
+				self.latest_state.batch.PutCF(self.cf_handles[col_prune_marks], prune_mark_key(col, k), prune_mark_written)
+			}
 			return
 		}
 		self.trie_value_key_buf.SetKey(k)
@@ -123,23 +173,161 @@ func (self *PendingBlockState) Put(col state_db.Column, k *common.Hash, v []byte
 	})
 }
 
+func (self *PendingBlockState) PutPreimage(hash *common.Hash, preimage []byte) {
+	if self.opts.DisablePreimages {
+		return
+	}
+	key := *hash
+	defer util.LockUnlock(&self.writes.mu)()
+	self.writes.preimages[key] = preimage
+	self.latest_state.writer_thread.Submit(func() {
+		self.latest_state.batch.PutCF(self.cf_handles[col_preimages], key[:], preimage)
+	})
+}
+
+// Flush writes the changes of the pending block made so far, so that they are not kept in memory until the commit.
+// The flushed changes are visible to the readers of the block before it is committed, so it is meant only for
+// building the first block of an empty database
+func (self *PendingBlockState) Flush() error {
+	defer util.LockUnlock(&self.writes.mu)()
+	self.latest_state.writer_thread.Join()
+	self.pruner.write_mu.Lock()
+	err := self.db.Write(self.latest_state.opts_w, self.latest_state.batch)
+	self.pruner.write_mu.Unlock()
+	if err != nil {
+		return err
+	}
+	self.latest_state.batch.Clear()
+	self.invalidate_versioned_read_pools()
+	for col := range self.writes.values {
+		self.writes.values[col] = make(map[common.Hash][]byte)
+	}
+	self.writes.preimages = make(map[common.Hash][]byte)
+	return nil
+}
+
 func (self *PendingBlockState) GetNumber() types.BlockNum {
 	return self.blk_n
 }
 
-func (self *LatestState) Commit(state_root common.Hash) (err error) {
-	state_desc := &state_db.StateDescriptor{BlockNum: self.pending_blk_n, StateRoot: state_root}
+// Commit makes the pending block the last committed one. The block is written in the background and is read from
+// memory until then. A write error is returned by the next Commit and by Barrier, the blocks committed after the failed
+// one are not written
+func (self *LatestState) Commit(state_root common.Hash) error {
+	if _, err := self.written(); err != nil {
+		return err
+	}
+	self.unwritten_cnt <- struct{}{}
+	self.state_desc_mu.Lock()
+	writes := self.pending_writes
+	if writes == nil {
+		writes = new_block_writes(self.pending_blk_n)
+	}
+	writes.state_desc = state_db.StateDescriptor{BlockNum: self.pending_blk_n, StateRoot: state_root}
+	unwritten := append(slices.Clone(*self.unwritten.Load()), writes)
+	self.unwritten.Store(&unwritten)
+	self.state_desc, self.pending_writes = writes.state_desc, nil
+	self.state_desc_mu.Unlock()
+	self.move_history_window(writes.state_desc.BlockNum)
 	self.writer_thread.Submit(func() {
-		self.batch.Put(last_committed_desc_key, rlp.MustEncodeToBytes(state_desc))
-		if err = self.db.Write(self.opts_w, self.batch); err != nil {
+		self.write_block(&writes.state_desc)
+	})
+	return nil
+}
+
+func (self *LatestState) write_block(state_desc *state_db.StateDescriptor) {
+	defer func() {
+		<-self.unwritten_cnt
+	}()
+	defer self.batch.Clear()
+	if _, err := self.written(); err != nil {
+		return
+	}
+	self.batch.Put(last_committed_desc_key, rlp.MustEncodeToBytes(state_desc))
+	self.batch.Put(state_root_key(state_desc.BlockNum), state_desc.StateRoot[:])
+	self.pruner.write_mu.Lock()
+	err := self.db.Write(self.opts_w, self.batch)
+	self.pruner.write_mu.Unlock()
+	if err != nil {
+		defer util.LockUnlock(&self.state_desc_mu)()
+		self.write_err = err
+		return
+	}
+	// the new versions are visible to the readers before the block is dropped from memory
+	self.invalidate_versioned_read_pools()
+	self.state_desc_mu.Lock()
+	self.written_desc = *state_desc
+	unwritten := (*self.unwritten.Load())[1:]
+	self.unwritten.Store(&unwritten)
+	self.state_desc_mu.Unlock()
+	self.on_write(state_desc.BlockNum)
+}
+
+// Barrier waits for the committed blocks to be written and returns the last written one
+func (self *LatestState) Barrier() (state_db.StateDescriptor, error) {
+	self.writer_thread.Join()
+	return self.written()
+}
+
+func (self *LatestState) written() (state_db.StateDescriptor, error) {
+	defer util.LockUnlock(self.state_desc_mu.RLocker())()
+	return self.written_desc, self.write_err
+}
+
+// get_unwritten reads the value from the committed blocks which are not written yet. The versions of the values are
+// read as of blk_n, the rest of the columns are not versioned
+func (self *LatestState) get_unwritten(col state_db.Column, k *common.Hash, blk_n types.BlockNum) (v []byte, ok bool) {
+	unwritten := *self.unwritten.Load()
+	if len(unwritten) == 0 {
+		return
+	}
+	if col == col_main_trie_value_latest {
+		col = state_db.COL_main_trie_value
+	} else if col == col_acc_trie_value_latest {
+		col = state_db.COL_acc_trie_value
+	} else if col >= state_db.COL_COUNT {
+		return
+	}
+	versioned := col == state_db.COL_main_trie_value || col == state_db.COL_acc_trie_value
+	for i := len(unwritten) - 1; i >= 0; i-- {
+		if versioned && blk_n < unwritten[i].state_desc.BlockNum {
+			continue
+		}
+		if v, ok = unwritten[i].values[col][*k]; ok {
 			return
 		}
-		self.invalidate_versioned_read_pools()
-		self.state_desc_mu.Lock()
-		self.state_desc = *state_desc
-		self.state_desc_mu.Unlock()
-	})
-	self.writer_thread.Join() // TODO completely async
-	self.writer_thread.Submit(self.batch.Clear)
+	}
 	return
 }
+
+func (self *LatestState) get_unwritten_preimage(hash *common.Hash) []byte {
+	unwritten := *self.unwritten.Load()
+	for i := len(unwritten) - 1; i >= 0; i-- {
+		if preimage := unwritten[i].preimages[*hash]; preimage != nil {
+			return preimage
+		}
+	}
+	return nil
+}
+
+func (self *LatestState) get_unwritten_state_root(blk_n types.BlockNum) *common.Hash {
+	for _, writes := range *self.unwritten.Load() {
+		if writes.state_desc.BlockNum == blk_n {
+			return new(common.Hash).SetBytes(writes.state_desc.StateRoot[:])
+		}
+	}
+	return nil
+}
+
+func (self *LatestState) committed_and_pending() (state_db.StateDescriptor, types.BlockNum) {
+	defer util.LockUnlock(self.state_desc_mu.RLocker())()
+	return self.state_desc, self.pending_blk_n
+}
+
+func (self *LatestState) reset(state_desc state_db.StateDescriptor) {
+	defer util.LockUnlock(&self.state_desc_mu)()
+	asserts.Holds(len(*self.unwritten.Load()) == 0)
+	self.state_desc, self.written_desc = state_desc, state_desc
+	self.pending_blk_n = state_desc.BlockNum
+	self.pending_writes = nil
+}
@@ -0,0 +1,80 @@
+package state_db_rocksdb
+
+import (
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/tests"
+)
+
+func TestAsyncCommit(t *testing.T) {
+	tc := tests.NewTestCtx(t)
+	defer tc.Close()
+	db := new(DB).Init(Opts{Path: tc.DataDir()})
+	defer db.Close()
+	latest_state := db.GetLatestState()
+	key, node_hash := common.Hash{1}, common.Hash{2}
+	read := func(blk_n types.BlockNum, col state_db.Column, k *common.Hash) (ret []byte) {
+		state_db.GetBlockStateReader(db, blk_n).Get(col, k, func(v []byte) { ret = common.CopyBytes(v) })
+		return
+	}
+	commit := func(value byte) {
+		blk := latest_state.BeginPendingBlock()
+		if value != 0 {
+			if v := read(blk.GetNumber()-1, state_db.COL_main_trie_value, &key); v != nil && v[0] != value-1 {
+				t.Fatalf("block %d: previous value %x", blk.GetNumber(), v)
+			}
+			var v []byte
+			blk.Get(state_db.COL_main_trie_value, &key, func(bytes []byte) { v = common.CopyBytes(bytes) })
+			if v[0] != value-1 {
+				t.Fatalf("block %d: latest value %x", blk.GetNumber(), v)
+			}
+		}
+		blk.Put(state_db.COL_main_trie_value, &key, []byte{value})
+		blk.Put(state_db.COL_main_trie_node, &node_hash, []byte{value})
+		if err := latest_state.Commit(common.Hash{value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit(0)
+
+	// the blocks which wait for the write are readable
+	release := make(chan struct{})
+	db.latest_state.writer_thread.Submit(func() { <-release })
+	for i := byte(1); i <= max_unwritten_blocks; i++ {
+		commit(i)
+		if state_desc := latest_state.GetCommittedDescriptor(); state_desc.BlockNum != types.BlockNum(i) {
+			t.Fatalf("committed block %d, expected %d", state_desc.BlockNum, i)
+		}
+		if root := db.GetStateRoot(types.BlockNum(i)); root == nil || *root != (common.Hash{i}) {
+			t.Fatalf("state root of the block %d: %v", i, root)
+		}
+	}
+	if written, _ := db.latest_state.written(); written.BlockNum != 0 {
+		t.Fatalf("block %d is written before the barrier", written.BlockNum)
+	}
+	for i := byte(0); i <= max_unwritten_blocks; i++ {
+		if v := read(types.BlockNum(i), state_db.COL_main_trie_value, &key); len(v) != 1 || v[0] != i {
+			t.Fatalf("block %d: value %x", i, v)
+		}
+	}
+	if v := read(0, state_db.COL_main_trie_node, &node_hash); len(v) != 1 || v[0] != max_unwritten_blocks {
+		t.Fatalf("node %x", v)
+	}
+	close(release)
+	state_desc, err := latest_state.Barrier()
+	if err != nil || state_desc != latest_state.GetCommittedDescriptor() {
+		t.Fatalf("written %+v, %v", state_desc, err)
+	}
+	if len(*db.latest_state.unwritten.Load()) != 0 {
+		t.Fatal("written blocks are kept in memory")
+	}
+	for i := byte(0); i <= max_unwritten_blocks; i++ {
+		if v := read(types.BlockNum(i), state_db.COL_main_trie_value, &key); len(v) != 1 || v[0] != i {
+			t.Fatalf("block %d after the write: value %x", i, v)
+		}
+	}
+	commit(max_unwritten_blocks + 1)
+}
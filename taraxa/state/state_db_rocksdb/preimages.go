@@ -0,0 +1,21 @@
+package state_db_rocksdb
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+)
+
+// GetPreimage returns the trie key by its hash. The preimages are written by the blocks unless
+// Opts.DisablePreimages is set, nil means that the preimage is unknown
+func (self *DB) GetPreimage(hash *common.Hash) []byte {
+	if preimage := self.latest_state.get_unwritten_preimage(hash); preimage != nil {
+		return preimage
+	}
+	v, err := self.db.GetCF(self.opts_r, self.cf_handles[col_preimages], hash[:])
+	util.PanicIfNotNil(err)
+	defer v.Free()
+	if data := v.Data(); len(data) != 0 {
+		return common.CopyBytes(data)
+	}
+	return nil
+}
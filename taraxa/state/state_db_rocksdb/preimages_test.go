@@ -0,0 +1,69 @@
+package state_db_rocksdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/tests"
+)
+
+func TestPreimages(t *testing.T) {
+	tc := tests.NewTestCtx(t)
+	defer tc.Close()
+	test, disabled := new_prune_test(t, tc.DataDir()+"/enabled"), new_prune_test(t, tc.DataDir()+"/disabled")
+	disabled.opts.DisablePreimages = true
+	test.open()
+	defer test.close()
+	disabled.open()
+	defer disabled.close()
+	for i := 0; i < 3; i++ {
+		test.block(byte(i))
+		disabled.block(byte(i))
+	}
+	slot := common.Hash{}
+	for _, addr := range append([]common.Address{test.sender}, test.contracts...) {
+		if preimage := test.db.GetPreimage(keccak256.Hash(addr[:])); !bytes.Equal(preimage, addr[:]) {
+			t.Fatalf("preimage of %x: %x", addr, preimage)
+		}
+		if preimage := disabled.db.GetPreimage(keccak256.Hash(addr[:])); preimage != nil {
+			t.Fatalf("preimage %x is stored while disabled", preimage)
+		}
+	}
+	if preimage := test.db.GetPreimage(keccak256.Hash(slot[:])); !bytes.Equal(preimage, slot[:]) {
+		t.Fatalf("preimage of the storage key: %x", preimage)
+	}
+
+	// the range reads resolve the addresses and the keys from the store
+	state_desc := test.api.GetCommittedStateDescriptor()
+	accounts, err := test.api.AccountRange(state_desc.BlockNum, &common.Hash{}, 100, state_db.KnownAddresses(nil, test.db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range accounts.Accounts {
+		if entry.Address == nil || *keccak256.Hash(entry.Address[:]) != entry.AddrHash {
+			t.Fatalf("address of %x is not resolved", entry.AddrHash)
+		}
+	}
+	storage, err := test.api.StorageRange(state_desc.BlockNum, &test.contracts[0], &common.Hash{}, 100, state_db.KnownKeys(nil, test.db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.Storage) != 1 || storage.Storage[0].Key == nil || *storage.Storage[0].Key != slot {
+		t.Fatalf("storage range %+v", storage)
+	}
+
+	// the blocks without the state root are not read as empty
+	delete_state_root(test.db, state_desc.BlockNum-1)
+	for _, blk_n := range []types.BlockNum{state_desc.BlockNum - 1, state_desc.BlockNum + 1} {
+		if _, err := test.api.AccountRange(blk_n, &common.Hash{}, 100, nil); err != state_db.ErrStateRootUnknown {
+			t.Fatalf("account range of block %d: %v", blk_n, err)
+		}
+		if _, err := test.api.StorageRange(blk_n, &test.contracts[0], &common.Hash{}, 100, nil); err != state_db.ErrStateRootUnknown {
+			t.Fatalf("storage range of block %d: %v", blk_n, err)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package state_db_rocksdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_common"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/trie"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+	"github.com/linxGnu/grocksdb"
+)
+
+// Prune is a mark and sweep done in the background in chunks of bounded size, blocks are committed meanwhile.
+// Nodes reachable from the kept state roots and from the storage roots of the kept trie values are marked in
+// col_prune_marks, the rest of the nodes are deleted. Nodes written during the prune are marked on write, so they
+// are never deleted. Progress is persisted together with each chunk, so the prune is resumed after restart
+
+var ErrPruneInProgress = errors.New("prune is already in progress")
+
+type PrunePhase = uint8
+
+const (
+	PrunePhaseMainTrieValues PrunePhase = iota
+	PrunePhaseAccTrieValues
+	PrunePhaseMarkMainTrie
+	PrunePhaseMarkAccTrie
+	PrunePhaseSweepMainTrie
+	PrunePhaseSweepAccTrie
+	prune_phase_finished
+)
+
+type PruneStatus struct {
+	InProgress bool
+	BlkNum     types.BlockNum
+	Phase      PrunePhase
+	// Number of the keys processed in the current phase
+	Processed uint64
+}
+
+type prune_state struct {
+	BlkNum           types.BlockNum
+	StateRootsToKeep []common.Hash
+	// Block which was pending when the prune started, its nodes may be not marked on write
+	PendingBlkNum types.BlockNum
+	Phase         PrunePhase
+	Cursor        []byte
+	Processed     uint64
+}
+
+type pruner struct {
+	// start and stop of the prune
+	mu     sync.Mutex
+	closed bool
+	// marking of the nodes on write
+	barrier atomic.Bool
+	// deletes of the sweep are not mixed with the writes of the blocks
+	write_mu  sync.Mutex
+	status    PruneStatus
+	status_mu sync.Mutex
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+var prune_state_key = []byte("prune_state")
+
+var (
+	prune_mark_written   = []byte{1}
+	prune_mark_traversed = []byte{2}
+)
+
+func prune_mark_key(col state_db.Column, k *common.Hash) []byte {
+	return append([]byte{col}, k[:]...)
+}
+
+// Prune starts deleting the state which is not needed for the state roots to keep and for the blocks starting from
+// blk_num. It returns right away, the progress is reported by GetPruneStatus
+func (self *DB) Prune(state_root_to_keep []common.Hash, blk_num types.BlockNum) error {
+	defer util.LockUnlock(&self.pruner.mu)()
+	return self.start_new_prune(state_root_to_keep, blk_num)
+}
+
+func (self *DB) start_new_prune(state_root_to_keep []common.Hash, blk_num types.BlockNum) error {
+	if self.GetPruneStatus().InProgress {
+		return ErrPruneInProgress
+	}
+	// marks left by the previous prune
+	self.write_sync(func(batch *grocksdb.WriteBatch) {
+		batch.DeleteRangeCF(self.cf_handles[col_prune_marks], []byte{0}, []byte{state_db.COL_COUNT})
+	})
+	self.pruner.barrier.Store(true)
+	_, pending_blk_n := self.latest_state.committed_and_pending()
+	state := prune_state{BlkNum: blk_num, StateRootsToKeep: state_root_to_keep, PendingBlkNum: pending_blk_n}
+	// the history below blk_num becomes not readable already during the prune
+	self.raise_history_start(blk_num)
+	self.write_sync(func(batch *grocksdb.WriteBatch) {
+		batch.Put(prune_state_key, rlp.MustEncodeToBytes(&state))
+		put_history_start(batch, self.HistoryStart())
+	})
+	self.start_prune(state)
+	return nil
+}
+
+func (self *DB) GetPruneStatus() PruneStatus {
+	defer util.LockUnlock(&self.pruner.status_mu)()
+	return self.pruner.status
+}
+
+func (self *DB) resume_prune() {
+	v, err := self.db.Get(self.opts_r, prune_state_key)
+	util.PanicIfNotNil(err)
+	defer v.Free()
+	if data := v.Data(); len(data) != 0 {
+		var state prune_state
+		rlp.MustDecodeBytes(data, &state)
+		self.pruner.barrier.Store(true)
+		self.start_prune(state)
+	}
+}
+
+func (self *DB) start_prune(state prune_state) {
+	self.set_prune_status(&state)
+	self.pruner.stop, self.pruner.done = make(chan struct{}), make(chan struct{})
+	go self.prune(state)
+}
+
+func (self *DB) close_pruner() {
+	defer util.LockUnlock(&self.pruner.mu)()
+	self.pruner.closed = true
+	self.stop_prune()
+}
+
+// stop_prune stops the prune in progress, the prune is continued by resume_prune. pruner.mu is held by the caller
+func (self *DB) stop_prune() {
+	if self.pruner.stop == nil {
+		return
+	}
+	close(self.pruner.stop)
+	<-self.pruner.done
+	self.pruner.stop, self.pruner.done = nil, nil
+}
+
+func (self *DB) prune_stopped() bool {
+	select {
+	case <-self.pruner.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (self *DB) set_prune_status(state *prune_state) {
+	defer util.LockUnlock(&self.pruner.status_mu)()
+	self.pruner.status = PruneStatus{state.Phase != prune_phase_finished, state.BlkNum, state.Phase, state.Processed}
+}
+
+func (self *DB) prune(state prune_state) {
+	defer close(self.pruner.done)
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	for state.Phase != prune_phase_finished {
+		if self.prune_stopped() {
+			return
+		}
+		var phase_finished, stopped bool
+		var sweep_col state_db.Column
+		var to_delete [][]byte
+		switch state.Phase {
+		case PrunePhaseMainTrieValues:
+			phase_finished = self.prune_values(&state, batch, state_db.COL_main_trie_value)
+		case PrunePhaseAccTrieValues:
+			if phase_finished = self.prune_values(&state, batch, state_db.COL_acc_trie_value); phase_finished {
+				if !self.wait_pending_block(&state) {
+					return
+				}
+				// nodes of the blocks committed since the prune start are reachable from the latest state root
+				if state_root := self.latest_state.GetCommittedDescriptor().StateRoot; !state_common.IsEmptyStateRoot(&state_root) {
+					state.StateRootsToKeep = append(state.StateRootsToKeep, state_root)
+				}
+			}
+		case PrunePhaseMarkMainTrie:
+			phase_finished, stopped = self.mark_main_trie(&state, batch)
+		case PrunePhaseMarkAccTrie:
+			phase_finished, stopped = self.mark_acc_trie(&state, batch)
+		case PrunePhaseSweepMainTrie:
+			sweep_col = state_db.COL_main_trie_node
+			to_delete, phase_finished = self.sweep(&state, sweep_col)
+		case PrunePhaseSweepAccTrie:
+			sweep_col = state_db.COL_acc_trie_node
+			to_delete, phase_finished = self.sweep(&state, sweep_col)
+		}
+		if stopped {
+			return
+		}
+		if phase_finished {
+			state.Phase++
+			state.Cursor, state.Processed = nil, 0
+		}
+		util.Call(func() {
+			defer util.LockUnlock(&self.pruner.write_mu)()
+			// the nodes may be written again by the blocks committed since they were checked
+			for _, k := range to_delete {
+				if !self.is_marked(sweep_col, k) {
+					batch.DeleteCF(self.cf_handles[sweep_col], k)
+				}
+			}
+			self.write_prune_chunk(&state, batch)
+		})
+	}
+	self.finish_prune()
+}
+
+func (self *DB) write_prune_chunk(state *prune_state, batch *grocksdb.WriteBatch) {
+	batch.Put(prune_state_key, rlp.MustEncodeToBytes(state))
+	util.PanicIfNotNil(self.db.Write(self.latest_state.opts_w, batch))
+	batch.Clear()
+	self.set_prune_status(state)
+}
+
+func (self *DB) finish_prune() {
+	self.pruner.barrier.Store(false)
+	self.write_sync(func(batch *grocksdb.WriteBatch) {
+		batch.DeleteRangeCF(self.cf_handles[col_prune_marks], []byte{0}, []byte{state_db.COL_COUNT})
+		batch.Delete(prune_state_key)
+	})
+	self.set_prune_status(&prune_state{Phase: prune_phase_finished})
+	range_limit := [common.VersionedKeyLength]byte{255}
+	full_range := grocksdb.Range{Start: make([]byte, common.VersionedKeyLength), Limit: range_limit[:]}
+	for _, col := range []state_db.Column{
+		state_db.COL_main_trie_value, state_db.COL_acc_trie_value, state_db.COL_main_trie_node, state_db.COL_acc_trie_node,
+	} {
+		self.db.CompactRangeCF(self.cf_handles[col], full_range)
+	}
+}
+
+// wait_pending_block waits for the block which was pending at the prune start to be committed or dropped
+func (self *DB) wait_pending_block(state *prune_state) bool {
+	for {
+		state_desc, pending_blk_n := self.latest_state.committed_and_pending()
+		if pending_blk_n == state_desc.BlockNum || state.PendingBlkNum <= state_desc.BlockNum && state_desc.BlockNum != types.BlockNumberNIL {
+			return true
+		}
+		select {
+		case <-self.pruner.stop:
+			return false
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// prune_values deletes the versions of the values which are overwritten before blk_num
+func (self *DB) prune_values(state *prune_state, batch *grocksdb.WriteBatch, col state_db.Column) (finished bool) {
+	itr := self.db.NewIteratorCF(self.opts_r_itr, self.cf_handles[col])
+	defer itr.Close()
+	var prev_key []byte
+	processed := 0
+	for itr.Seek(state.Cursor); itr.Valid(); itr.Next() {
+		key := itr.Key().Data()
+		same_key := prev_key != nil && bytes.Equal(prev_key[:common.HashLength], key[:common.HashLength])
+		// chunks end between the keys, so that the last version of the key is known
+		if !same_key && processed >= db_prune_buffer_max_size {
+			state.Cursor = common.CopyBytes(key[:common.HashLength])
+			return false
+		}
+		if same_key && binary.BigEndian.Uint64(key[common.HashLength:]) < state.BlkNum {
+			batch.DeleteCF(self.cf_handles[col], prev_key)
+		}
+		prev_key = common.CopyBytes(key)
+		processed++
+		state.Processed++
+	}
+	util.PanicIfNotNil(itr.Err())
+	return true
+}
+
+func (self *DB) marker(state *prune_state, batch *grocksdb.WriteBatch, col state_db.Column) (skip, mark func(*common.Hash) bool) {
+	chunk_marks := make(map[common.Hash]bool)
+	skip = func(h *common.Hash) bool {
+		if chunk_marks[*h] {
+			return true
+		}
+		v, err := self.db.GetCF(self.opts_r, self.cf_handles[col_prune_marks], prune_mark_key(col, h))
+		util.PanicIfNotNil(err)
+		defer v.Free()
+		// nodes marked on write are traversed, as their children may be not marked yet
+		return bytes.Equal(v.Data(), prune_mark_traversed)
+	}
+	mark = func(h *common.Hash) bool {
+		batch.PutCF(self.cf_handles[col_prune_marks], prune_mark_key(col, h), prune_mark_traversed)
+		chunk_marks[*h] = true
+		state.Processed++
+		// nodes are marked after their children, so any prefix of the marks can be written
+		if batch.Count() >= db_prune_buffer_max_size {
+			self.write_prune_chunk(state, batch)
+			chunk_marks = make(map[common.Hash]bool)
+		}
+		return !self.prune_stopped()
+	}
+	return
+}
+
+func (self *DB) mark_main_trie(state *prune_state, batch *grocksdb.WriteBatch) (finished, stopped bool) {
+	i := 0
+	if state.Cursor != nil {
+		i = int(binary.BigEndian.Uint64(state.Cursor))
+	}
+	if i == len(state.StateRootsToKeep) {
+		return true, false
+	}
+	skip, mark := self.marker(state, batch, state_db.COL_main_trie_node)
+	input := state_db.MainTrieInputAdapter{self.node_reader()}
+	if !trie.ForEachNodeHashPostOrder(input, &state.StateRootsToKeep[i], skip, mark) {
+		return false, true
+	}
+	state.Cursor = binary.BigEndian.AppendUint64(nil, uint64(i+1))
+	return false, false
+}
+
+func (self *DB) mark_acc_trie(state *prune_state, batch *grocksdb.WriteBatch) (finished, stopped bool) {
+	skip, mark := self.marker(state, batch, state_db.COL_acc_trie_node)
+	no_addr := common.Address{}
+	input := state_db.AccountTrieInputAdapter{&no_addr, self.node_reader()}
+	itr := self.db.NewIteratorCF(self.opts_r_itr, self.cf_handles[state_db.COL_main_trie_value])
+	defer itr.Close()
+	processed := 0
+	for itr.Seek(state.Cursor); itr.Valid(); itr.Next() {
+		if processed >= db_prune_buffer_max_size {
+			state.Cursor = common.CopyBytes(itr.Key().Data())
+			return false, false
+		}
+		processed++
+		v := itr.Value().Data()
+		if len(v) == 0 {
+			continue
+		}
+		if acc := state_db.DecodeAccountFromTrie(v); acc.StorageRootHash != nil {
+			if !trie.ForEachNodeHashPostOrder(input, acc.StorageRootHash, skip, mark) {
+				return false, true
+			}
+		}
+	}
+	util.PanicIfNotNil(itr.Err())
+	return true, false
+}
+
+// node_reader reads the nodes, which are not versioned, regardless of the history window
+func (self *DB) node_reader() state_db.Reader {
+	return block_state_reader{self, self.HistoryStart()}
+}
+
+func (self *DB) is_marked(col state_db.Column, k []byte) bool {
+	v, err := self.db.GetCF(self.opts_r, self.cf_handles[col_prune_marks], append([]byte{col}, k...))
+	util.PanicIfNotNil(err)
+	defer v.Free()
+	return len(v.Data()) != 0
+}
+
+// sweep finds the nodes which are not marked
+func (self *DB) sweep(state *prune_state, col state_db.Column) (to_delete [][]byte, finished bool) {
+	itr := self.db.NewIteratorCF(self.opts_r_itr, self.cf_handles[col])
+	defer itr.Close()
+	processed := 0
+	for itr.Seek(state.Cursor); itr.Valid(); itr.Next() {
+		key := itr.Key().Data()
+		if processed >= db_prune_buffer_max_size {
+			state.Cursor = common.CopyBytes(key)
+			return
+		}
+		if !self.is_marked(col, key) {
+			to_delete = append(to_delete, common.CopyBytes(key))
+		}
+		processed++
+		state.Processed++
+	}
+	util.PanicIfNotNil(itr.Err())
+	return to_delete, true
+}
@@ -0,0 +1,220 @@
+package state_db_rocksdb
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/chain_config"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/bigutil"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/tests"
+)
+
+type prune_test struct {
+	t         *testing.T
+	path      string
+	opts      Opts
+	cfg       chain_config.ChainConfig
+	db        *DB
+	api       *state.API
+	sender    common.Address
+	contracts []common.Address
+}
+
+func (self *prune_test) open() {
+	self.opts.Path = self.path
+	self.db = new(DB).Init(self.opts)
+	self.api = new(state.API).Init(self.db, func(types.BlockNum) *big.Int { panic("unexpected") }, &self.cfg, state.APIOpts{})
+}
+
+func (self *prune_test) close() {
+	self.api.Close()
+	self.db.Close()
+}
+
+func (self *prune_test) execute(trxs ...vm.Transaction) {
+	st := self.api.GetStateTransition()
+	st.BeginBlock(&vm.BlockInfo{})
+	for i := range trxs {
+		trxs[i].From, trxs[i].Gas, trxs[i].GasPrice = self.sender, 1000000, big.NewInt(0)
+		trxs[i].Nonce = bigutil.Add(self.nonce(), big.NewInt(int64(i+1)))
+		if trxs[i].Value == nil {
+			trxs[i].Value = big.NewInt(0)
+		}
+		if res := st.ExecuteTransaction(&trxs[i]); res.ExecutionErr != "" || res.ConsensusErr != "" {
+			self.t.Fatal(res.ExecutionErr, res.ConsensusErr)
+		} else if trxs[i].To == nil {
+			self.contracts = append(self.contracts, res.NewContractAddr)
+		}
+	}
+	st.EndBlock()
+	st.Commit()
+}
+
+func (self *prune_test) nonce() (ret *big.Int) {
+	ret = big.NewInt(0)
+	self.api.ReadBlock(self.api.GetCommittedStateDescriptor().BlockNum).GetAccount(&self.sender, func(acc state_db.Account) {
+		ret = acc.Nonce
+	})
+	return
+}
+
+// block deploys a contract and changes the storage of all the deployed contracts
+func (self *prune_test) block(value byte) {
+	// runtime code stores the first word of the input to the slot 0
+	init_code := common.Hex2Bytes("666000356000550060005260076019f3")
+	trxs := []vm.Transaction{{Input: init_code}}
+	for i := range self.contracts {
+		trxs = append(trxs, vm.Transaction{To: &self.contracts[i], Input: common.BytesToHash([]byte{value, byte(i)}).Bytes()})
+	}
+	self.execute(trxs...)
+}
+
+func (self *prune_test) node_count(col state_db.Column) (ret int) {
+	if _, err := self.db.latest_state.Barrier(); err != nil {
+		self.t.Fatal(err)
+	}
+	itr := self.db.db.NewIteratorCF(self.db.opts_r_itr, self.db.cf_handles[col])
+	defer itr.Close()
+	for itr.SeekToFirst(); itr.Valid(); itr.Next() {
+		ret++
+	}
+	return
+}
+
+func (self *prune_test) wait_prune() {
+	for self.db.GetPruneStatus().InProgress {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// check reads the whole latest state
+func (self *prune_test) check(value byte) {
+	state_desc := self.api.GetCommittedStateDescriptor()
+	reader := state_db.GetBlockStateReader(self.db, state_desc.BlockNum)
+	reader.ForEachMainNodeHashByRoot(&state_desc.StateRoot, func(*common.Hash, []byte) {})
+	for i := range self.contracts {
+		var acc state_db.Account
+		reader.GetAccount(&self.contracts[i], func(a state_db.Account) { acc = a })
+		reader.ForEachAccountNodeHashByRoot(acc.StorageRootHash, func(*common.Hash, []byte) {})
+		expected := common.BytesToHash([]byte{value, byte(i)})
+		if i == len(self.contracts)-1 {
+			// deployed by the last block
+			expected = common.Hash{}
+		}
+		var actual common.Hash
+		reader.GetAccountStorage(&self.contracts[i], &common.Hash{}, func(v []byte) { actual = common.BytesToHash(v) })
+		if actual != expected {
+			self.t.Fatalf("contract %d: storage value %x, expected %x", i, actual, expected)
+		}
+	}
+}
+
+func new_prune_test(t *testing.T, path string) *prune_test {
+	test := &prune_test{t: t, path: path, sender: common.Address{1}}
+	test.cfg.GenesisBalances = core.BalanceMap{test.sender: big.NewInt(1e18)}
+	test.cfg.DPOS = chain_config.DPOSConfig{
+		EligibilityBalanceThreshold: big.NewInt(1),
+		VoteEligibilityBalanceStep:  big.NewInt(1),
+		ValidatorMaximumStake:       big.NewInt(1e18),
+		MinimumDeposit:              big.NewInt(1),
+		BlocksPerYear:               1000,
+	}
+	test.cfg.Hardforks.AspenHf = chain_config.AspenHfConfig{MaxSupply: big.NewInt(1e18), GeneratedRewards: big.NewInt(0)}
+	return test
+}
+
+func TestPrune(t *testing.T) {
+	tc := tests.NewTestCtx(t)
+	defer tc.Close()
+	test := new_prune_test(t, tc.DataDir())
+	test.open()
+	for i := 0; i < 20; i++ {
+		test.block(byte(i))
+	}
+	main_nodes, acc_nodes := test.node_count(state_db.COL_main_trie_node), test.node_count(state_db.COL_acc_trie_node)
+
+	state_desc := test.api.GetCommittedStateDescriptor()
+	if err := test.db.Prune([]common.Hash{state_desc.StateRoot}, state_desc.BlockNum); err != nil {
+		t.Fatal(err)
+	}
+	// blocks are committed during the prune
+	for i := 20; test.db.GetPruneStatus().InProgress; i++ {
+		test.block(byte(i))
+		test.check(byte(i))
+	}
+	test.block(100)
+	test.check(100)
+	if test.node_count(state_db.COL_main_trie_node) >= main_nodes || test.node_count(state_db.COL_acc_trie_node) >= acc_nodes {
+		t.Fatal("nodes are not pruned")
+	}
+
+	// prune is resumed after restart
+	state_desc = test.api.GetCommittedStateDescriptor()
+	if err := test.db.Prune([]common.Hash{state_desc.StateRoot}, state_desc.BlockNum); err != nil {
+		t.Fatal(err)
+	}
+	test.close()
+	test.open()
+	defer test.close()
+	test.wait_prune()
+	if test.node_count(col_prune_marks) != 0 {
+		t.Fatal("marks are not deleted")
+	}
+	test.check(100)
+	test.block(101)
+	test.check(101)
+}
+
+func TestHistoryDepth(t *testing.T) {
+	tc := tests.NewTestCtx(t)
+	defer tc.Close()
+	archive, test := new_prune_test(t, tc.DataDir()+"/archive"), new_prune_test(t, tc.DataDir()+"/window")
+	test.opts.HistoryDepth = 5
+	archive.open()
+	defer archive.close()
+	test.open()
+	for i := 0; i < 20; i++ {
+		archive.block(byte(i))
+		test.block(byte(i))
+		test.check(byte(i))
+	}
+	test.wait_prune()
+	if test.node_count(state_db.COL_main_trie_node) >= archive.node_count(state_db.COL_main_trie_node) ||
+		test.node_count(state_db.COL_acc_trie_node) >= archive.node_count(state_db.COL_acc_trie_node) {
+		t.Fatal("nodes are not pruned")
+	}
+
+	read_account := func(blk_n types.BlockNum) (err interface{}) {
+		defer func() { err = recover() }()
+		test.api.ReadBlock(blk_n).GetAccount(&test.sender, func(state_db.Account) {})
+		return
+	}
+	last_blk_n := test.api.GetCommittedStateDescriptor().BlockNum
+	if err := read_account(last_blk_n - 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := read_account(last_blk_n - 5).(state_db.ErrStatePruned); !ok {
+		t.Fatal("block out of the history is readable")
+	}
+	if err := test.db.RevertTo(last_blk_n - 5); err != state_db.ErrRevertToPrunedBlock {
+		t.Fatalf("revert to a pruned block: %v", err)
+	}
+
+	// the window is restored after restart
+	test.close()
+	test.open()
+	defer test.close()
+	if _, ok := read_account(last_blk_n - 5).(state_db.ErrStatePruned); !ok {
+		t.Fatal("block out of the history is readable after restart")
+	}
+	test.check(19)
+	test.block(20)
+	test.check(20)
+}
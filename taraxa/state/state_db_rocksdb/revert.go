@@ -0,0 +1,161 @@
+package state_db_rocksdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+	"github.com/linxGnu/grocksdb"
+)
+
+var state_root_key_prefix = []byte("state_root_")
+
+var revert_in_progress_key = []byte("revert_in_progress")
+
+func state_root_key(blk_n types.BlockNum) []byte {
+	return binary.BigEndian.AppendUint64(common.CopyBytes(state_root_key_prefix), blk_n)
+}
+
+func (self *DB) GetStateRoot(blk_n types.BlockNum) (ret *common.Hash) {
+	if ret = self.latest_state.get_unwritten_state_root(blk_n); ret != nil {
+		return
+	}
+	v, err := self.db.Get(self.opts_r, state_root_key(blk_n))
+	util.PanicIfNotNil(err)
+	defer v.Free()
+	if data := v.Data(); len(data) != 0 {
+		ret = new(common.Hash)
+		ret.SetBytes(data)
+	}
+	return
+}
+
+// RevertTo removes everything committed after blk_n and makes it the last committed block.
+// The revert is done in several writes, so if it is interrupted it is resumed on the next Init.
+// State roots are stored only for the blocks committed since the revert support was added, so reverting to an older
+// block fails with ErrStateRootUnknown
+func (self *DB) RevertTo(blk_n types.BlockNum) error {
+	if _, err := self.latest_state.Barrier(); err != nil {
+		return err
+	}
+	state_desc := self.latest_state.GetCommittedDescriptor()
+	if state_desc.BlockNum == types.BlockNumberNIL || state_desc.BlockNum < blk_n {
+		return state_db.ErrRevertToFutureBlock
+	}
+	if state_desc.BlockNum == blk_n {
+		return nil
+	}
+	if self.GetStateRoot(blk_n) == nil {
+		return state_db.ErrStateRootUnknown
+	}
+	if blk_n < self.HistoryStart() {
+		return state_db.ErrRevertToPrunedBlock
+	}
+	defer util.LockUnlock(&self.pruner.mu)()
+	self.stop_prune()
+	defer self.resume_prune()
+	// drop the pending block
+	self.latest_state.writer_thread.Join()
+	self.latest_state.batch.Clear()
+	self.write_sync(func(batch *grocksdb.WriteBatch) {
+		batch.Put(revert_in_progress_key, binary.BigEndian.AppendUint64(nil, blk_n))
+	})
+	self.revert(blk_n)
+	return nil
+}
+
+func (self *DB) resume_revert() {
+	v, err := self.db.Get(self.opts_r, revert_in_progress_key)
+	util.PanicIfNotNil(err)
+	defer v.Free()
+	if data := v.Data(); len(data) != 0 {
+		self.revert(binary.BigEndian.Uint64(data))
+	}
+}
+
+func (self *DB) revert(blk_n types.BlockNum) {
+	self.revert_versioned_column(blk_n, state_db.COL_main_trie_value, col_main_trie_value_latest)
+	self.revert_versioned_column(blk_n, state_db.COL_acc_trie_value, col_acc_trie_value_latest)
+	self.write_sync(func(batch *grocksdb.WriteBatch) {
+		itr := self.db.NewIteratorCF(self.opts_r_itr, self.cf_handles[col_config_changes])
+		defer itr.Close()
+		for itr.SeekToFirst(); itr.Valid(); itr.Next() {
+			if key := itr.Key().Data(); new(big.Int).SetBytes(key).Uint64() > blk_n {
+				batch.DeleteCF(self.cf_handles[col_config_changes], common.CopyBytes(key))
+			}
+		}
+		util.PanicIfNotNil(itr.Err())
+	})
+	state_desc := state_db.StateDescriptor{BlockNum: blk_n, StateRoot: *self.GetStateRoot(blk_n)}
+	self.write_sync(func(batch *grocksdb.WriteBatch) {
+		itr := self.db.NewIterator(self.opts_r_itr)
+		defer itr.Close()
+		for itr.Seek(state_root_key(blk_n + 1)); itr.ValidForPrefix(state_root_key_prefix); itr.Next() {
+			batch.Delete(common.CopyBytes(itr.Key().Data()))
+		}
+		util.PanicIfNotNil(itr.Err())
+		batch.Put(last_committed_desc_key, rlp.MustEncodeToBytes(&state_desc))
+		batch.Delete(revert_in_progress_key)
+	})
+	self.invalidate_versioned_read_pools()
+	self.latest_state.reset(state_desc)
+}
+
+// revert_versioned_column deletes the versions newer than blk_n and restores the most recent value views.
+// Versions of a key are ordered by the block number, so for every key the iterator seeks right to the first version
+// newer than blk_n and older versions are not read. All the changes of the key are written together, so the column is
+// consistent after each write
+func (self *DB) revert_versioned_column(blk_n types.BlockNum, col, col_latest state_db.Column) {
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	write := func() {
+		util.PanicIfNotNil(self.db.Write(self.latest_state.opts_w, batch))
+		batch.Clear()
+	}
+	itr := self.db.NewIteratorCF(self.opts_r_itr, self.cf_handles[col])
+	defer itr.Close()
+	kept_itr := self.db.NewIteratorCF(self.opts_r_itr, self.cf_handles[col])
+	defer kept_itr.Close()
+	var key common.Hash
+	var versioned_key VersionedKey
+	for itr.SeekToFirst(); itr.Valid(); {
+		key.SetBytes(itr.Key().Data()[:common.HashLength])
+		versioned_key.SetKey(&key)
+		versioned_key.SetVersion(blk_n + 1)
+		reverted := false
+		for itr.Seek(versioned_key[:]); itr.Valid() && bytes.HasPrefix(itr.Key().Data(), key[:]); itr.Next() {
+			batch.DeleteCF(self.cf_handles[col], common.CopyBytes(itr.Key().Data()))
+			reverted = true
+		}
+		if !reverted || self.opts.DisableMostRecentTrieValueViews {
+			continue
+		}
+		versioned_key.SetVersion(blk_n)
+		if kept_itr.SeekForPrev(versioned_key[:]); kept_itr.Valid() && bytes.HasPrefix(kept_itr.Key().Data(), key[:]) {
+			batch.PutCF(self.cf_handles[col_latest], key[:], common.CopyBytes(kept_itr.Value().Data()))
+		} else {
+			batch.DeleteCF(self.cf_handles[col_latest], key[:])
+		}
+		util.PanicIfNotNil(kept_itr.Err())
+		if batch.Count() > db_prune_buffer_max_size {
+			write()
+		}
+	}
+	util.PanicIfNotNil(itr.Err())
+	write()
+}
+
+func (self *DB) write_sync(fill func(*grocksdb.WriteBatch)) {
+	batch := grocksdb.NewWriteBatch()
+	defer batch.Destroy()
+	fill(batch)
+	opts_w := grocksdb.NewDefaultWriteOptions()
+	defer opts_w.Destroy()
+	opts_w.SetSync(true)
+	util.PanicIfNotNil(self.db.Write(opts_w, batch))
+}
@@ -0,0 +1,64 @@
+package state_db_rocksdb
+
+import (
+	"testing"
+
+	"github.com/linxGnu/grocksdb"
+
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/tests"
+)
+
+func TestRevertTo(t *testing.T) {
+	tc := tests.NewTestCtx(t)
+	defer tc.Close()
+	test := new_prune_test(t, tc.DataDir())
+	test.open()
+	blk_nums, contract_counts := make([]types.BlockNum, 10), make([]int, 10)
+	for i := range blk_nums {
+		test.block(byte(i))
+		blk_nums[i], contract_counts[i] = test.api.GetCommittedStateDescriptor().BlockNum, len(test.contracts)
+	}
+
+	if err := test.api.RevertTo(blk_nums[9] + 1); err != state_db.ErrRevertToFutureBlock {
+		t.Fatalf("revert to a future block: %v", err)
+	}
+	// blocks committed before the revert support was added have no state root stored
+	delete_state_root(test.db, blk_nums[2])
+	if err := test.api.RevertTo(blk_nums[2]); err != state_db.ErrStateRootUnknown {
+		t.Fatalf("revert to a block without state root: %v", err)
+	}
+	test.check(9)
+
+	if err := test.api.RevertTo(blk_nums[5]); err != nil {
+		t.Fatal(err)
+	}
+	if test.api.GetCommittedStateDescriptor().BlockNum != blk_nums[5] {
+		t.Fatal("block is not reverted")
+	}
+	test.contracts = test.contracts[:contract_counts[5]]
+	test.check(5)
+	for _, blk_n := range blk_nums[6:] {
+		if test.db.GetStateRoot(blk_n) != nil {
+			t.Fatalf("state root of the reverted block %d is kept", blk_n)
+		}
+	}
+
+	// reverted state is kept after restart and new blocks are built on top of it
+	test.close()
+	test.open()
+	defer test.close()
+	test.check(5)
+	test.block(6)
+	test.check(6)
+	if test.api.GetCommittedStateDescriptor().BlockNum != blk_nums[6] {
+		t.Fatal("unexpected block number after the revert")
+	}
+}
+
+func delete_state_root(db *DB, blk_n types.BlockNum) {
+	db.write_sync(func(batch *grocksdb.WriteBatch) {
+		batch.Delete(state_root_key(blk_n))
+	})
+}
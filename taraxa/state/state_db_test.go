@@ -31,3 +31,44 @@ func TestStateDBTransientStorage(t *testing.T) {
 		t.Fatalf("transient storage mismatch: have %x, want %x", got, exp)
 	}
 }
+
+func TestStateDBAccessList(t *testing.T) {
+	var state state_evm.TransitionState
+	state.Init(state_evm.Opts{
+		NumTransactionsToBuffer: 1,
+	})
+
+	addr_a, addr_b := common.Address{0xaa}, common.Address{0xbb}
+	slot_1, slot_2 := common.Hash{0x01}, common.Hash{0x02}
+
+	state.PrepareAccessList()
+	state.AddAddressToAccessList(&addr_a)
+	snapshot := state.Snapshot()
+	state.AddSlotToAccessList(&addr_a, &slot_1)
+	state.AddSlotToAccessList(&addr_b, &slot_2)
+
+	if !state.AddressInAccessList(&addr_b) {
+		t.Fatalf("expected %x to be in the access list", addr_b)
+	}
+	if addr_ok, slot_ok := state.SlotInAccessList(&addr_a, &slot_1); !addr_ok || !slot_ok {
+		t.Fatalf("expected (%x, %x) to be in the access list", addr_a, slot_1)
+	}
+
+	// changes made after the snapshot should be rolled back
+	state.RevertToSnapshot(snapshot)
+	if !state.AddressInAccessList(&addr_a) {
+		t.Fatalf("expected %x to stay in the access list", addr_a)
+	}
+	if addr_ok, slot_ok := state.SlotInAccessList(&addr_a, &slot_1); !addr_ok || slot_ok {
+		t.Fatalf("expected only %x to be in the access list, without slot %x", addr_a, slot_1)
+	}
+	if state.AddressInAccessList(&addr_b) {
+		t.Fatalf("expected %x to be removed from the access list", addr_b)
+	}
+
+	// access list is per transaction
+	state.CommitTransaction(nil)
+	if state.AddressInAccessList(&addr_a) {
+		t.Fatalf("expected access list to be cleared after transaction")
+	}
+}
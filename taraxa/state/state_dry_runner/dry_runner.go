@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"github.com/Taraxa-project/taraxa-evm/accounts/abi"
+	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/core/types"
 	"github.com/Taraxa-project/taraxa-evm/core/vm"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/state/chain_config"
@@ -42,23 +43,161 @@ func (self *DryRunner) UpdateConfig(cfg *chain_config.ChainConfig) {
 	self.chain_config = cfg
 }
 
-func (self *DryRunner) Apply(blk *vm.Block, trx *vm.Transaction) vm.ExecutionResult {
+// Apply executes the transaction against the state of blk.Number. Overrides are applied on top of the state
+// and the block without touching the db
+func (self *DryRunner) Apply(blk *vm.Block, trx *vm.Transaction, state_overrides StateOverrides, block_overrides *BlockOverrides) vm.ExecutionResult {
+	block_state := self.prepare(blk, trx, state_overrides)
+	evm := self.newEVM(block_overrides.Apply(blk), block_state)
+	self.registerContracts(evm, blk.Number, contract_storage.EVMStateStorage{block_state})
+	ret, err := evm.Main(trx)
+	appendRevertReason(&ret, err)
+	return ret
+}
+
+type GasEstimation struct {
+	Gas          uint64
+	CodeRetval   []byte
+	ExecutionErr util.ErrorString
+	ConsensusErr util.ErrorString
+}
+
+// fallback_gas_cap is the gas cap of the estimation if neither the cap nor any block gas limit is known
+const fallback_gas_cap = uint64(100000000)
+
+// EstimateGas finds the minimal gas limit the transaction succeeds with. Gas limits up to gas_cap are binary searched,
+// all executions are done by the same EVM on the same block state which is reverted after each of them. Precompiled
+// contracts are registered anew for every execution as they cache their storage. 0 gas_cap means the block gas limit.
+// If the transaction fails with gas_cap, the error (including the revert reason) is returned
+func (self *DryRunner) EstimateGas(blk *vm.Block, trx *vm.Transaction, gas_cap uint64) (ret GasEstimation) {
+	block_state := self.prepare(blk, trx, nil)
+	evm := self.newEVM(blk, block_state)
+	execute := func(gas uint64) (res vm.ExecutionResult, refund uint64, failed bool) {
+		snapshot := block_state.Snapshot()
+		defer func() {
+			block_state.RevertToSnapshot(snapshot)
+			block_state.ResetTransientState()
+		}()
+		evm.ResetPrecompiledContracts()
+		self.registerContracts(evm, blk.Number, revertibleStorage{contract_storage.EVMStateStorage{block_state}})
+		// precompiled contracts can modify the input in place, so every execution gets its own copy
+		trx_copy := *trx
+		trx_copy.Gas, trx_copy.Input = gas, common.CopyBytes(trx.Input)
+		res, err := evm.Main(&trx_copy)
+		appendRevertReason(&res, err)
+		return res, util.MinU64(block_state.GetRefund(), res.GasUsed), res.ConsensusErr != "" || res.ExecutionErr != ""
+	}
+
+	rules := self.chain_config.Hardforks.Rules(blk.Number)
+	if gas_cap == 0 {
+		gas_cap = blk.GasLimit
+	}
+	if gas_cap == 0 {
+		gas_cap = self.chain_config.Hardforks.CornusHf.PbftGasLimit
+	}
+	if gas_cap == 0 {
+		gas_cap = fallback_gas_cap
+	}
+	for _, limit := range []uint64{rules.TrxMaxGasLimit, rules.DagGasLimit} {
+		if limit != 0 && gas_cap > limit {
+			gas_cap = limit
+		}
+	}
+	// sender can't pay for more gas than its balance allows
+	fee_cap := trx.GasPrice
+	if rules.IsLantana && trx.MaxFeePerGas != nil {
+		fee_cap = trx.MaxFeePerGas
+	}
+	if fee_cap != nil && fee_cap.Sign() != 0 {
+		balance := new(big.Int).Sub(block_state.GetAccount(&trx.From).GetBalance(), bigutil.ZeroIfNIL(trx.Value))
+		if balance.Sign() < 0 {
+			balance.SetUint64(0)
+		}
+		if allowance := balance.Div(balance, fee_cap); allowance.IsUint64() && allowance.Uint64() < gas_cap {
+			gas_cap = allowance.Uint64()
+		}
+	}
+
+	res, refund, failed := execute(gas_cap)
+	if failed {
+		return GasEstimation{gas_cap, res.CodeRetval, res.ExecutionErr, res.ConsensusErr}
+	}
+	// Gas used is reduced by refunds, and because of the 63/64 rule the transaction can need more gas than it uses.
+	// So the used gas is only the lower bound of the search
+	lo, hi := res.GasUsed-1, gas_cap
+	// Most of the transactions pass with the gas used before the refund plus the call stipend
+	// increased according to the 63/64 rule, so it is tried first to cut the number of iterations
+	if optimistic := (res.GasUsed + refund + vm.CallStipend) * 64 / 63; optimistic < hi {
+		if _, _, failed := execute(optimistic); failed {
+			lo = optimistic
+		} else {
+			hi = optimistic
+		}
+	}
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		if _, _, failed := execute(mid); failed {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	ret.Gas = hi
+	return
+}
+
+func (self *DryRunner) prepare(blk *vm.Block, trx *vm.Transaction, state_overrides StateOverrides) *state_evm.BlockState {
 	block_state := state_evm.GetBlockState(self.db, blk.Number, 1)
+	if len(state_overrides) != 0 {
+		block_state.SetInput(newOverriddenInput(block_state.In(), state_overrides))
+	}
 	// we don't need to specify nonce for eth_call. So set correct one
 	trx.Nonce = bigutil.Add(block_state.GetAccount(&trx.From).GetNonce(), big.NewInt(1))
-	var evm vm.EVM
-	evm.Init(self.get_block_hash, block_state, vm.DefaultOpts(), self.chain_config.EVMChainConfig, vm.Config{})
+	return block_state
+}
+
+// newEVM creates EVM for the block, precompiled contracts are registered separately
+func (self *DryRunner) newEVM(blk *vm.Block, block_state *state_evm.BlockState) *vm.EVM {
+	evm := new(vm.EVM)
+	evm.Init(self.get_block_hash, block_state, vm.DefaultOpts(), self.chain_config.EVMChainConfig, vm.Config{NoBaseFee: true})
 	evm.SetBlock(blk, self.chain_config.Hardforks.Rules(blk.Number))
+	return evm
+}
+
+// registerContracts registers precompiled contracts reading the state of state_blk_n, replacing the previous ones
+func (self *DryRunner) registerContracts(evm *vm.EVM, state_blk_n types.BlockNum, storage contract_storage.Storage) {
 	if self.dpos_api != nil {
-		self.dpos_api.InitAndRegisterAllContracts(contract_storage.EVMStateStorage{block_state}, blk.Number, self.get_reader, &evm, evm.RegisterPrecompiledContract)
+		self.dpos_api.InitAndRegisterAllContracts(storage, state_blk_n, self.get_reader, evm, evm.RegisterPrecompiledContract)
 	}
+}
 
-	ret, err := evm.Main(trx)
+func appendRevertReason(ret *vm.ExecutionResult, err error) {
 	if err == vm.ErrExecutionReverted {
 		reason, unpack_err := abi.UnpackRevert(ret.CodeRetval)
 		if unpack_err == nil {
 			ret.ExecutionErr += util.ErrorString(": " + reason)
 		}
 	}
-	return ret
+}
+
+// revertibleStorage makes storage writes of the precompiled contracts revertible, so that they are undone together
+// with the rest of the state on RevertToSnapshot
+type revertibleStorage struct {
+	contract_storage.EVMStateStorage
+}
+
+func (self revertibleStorage) Put(address *common.Address, k *common.Hash, v []byte) {
+	acc, key := self.GetAccountConcrete(address), *k
+	var prev []byte
+	var was_dirty bool
+	if !acc.IsNIL() {
+		prev, was_dirty = acc.RawStorageDirty[key]
+	}
+	acc.SetStateRawIrreversibly(&key, v)
+	self.RegisterChange(func() {
+		if was_dirty {
+			acc.RawStorageDirty[key] = prev
+		} else {
+			delete(acc.RawStorageDirty, key)
+		}
+	})
 }
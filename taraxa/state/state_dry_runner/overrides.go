@@ -0,0 +1,163 @@
+package state_dry_runner
+
+import (
+	"math/big"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_evm"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+)
+
+type StorageEntry struct {
+	Key   common.Hash
+	Value common.Hash
+}
+
+// AccountOverride replaces fields of the account for a dry run. Empty values mean no override.
+// State replaces the whole account storage, StateDiff patches only the given slots. As an empty State can't be told
+// apart from no override, ReplaceState makes the State override apply even if it is empty, wiping the storage
+type AccountOverride struct {
+	Address      common.Address
+	Balance      *big.Int `rlp:"nil"`
+	Nonce        *big.Int `rlp:"nil"`
+	Code         []byte
+	State        []StorageEntry
+	StateDiff    []StorageEntry
+	ReplaceState bool `rlp:"optional"`
+}
+
+type StateOverrides []AccountOverride
+
+// BlockOverrides replaces fields of the block for a dry run. Empty values mean no override.
+// Number doesn't change the state the dry run is executed against
+type BlockOverrides struct {
+	Number   types.BlockNum
+	Time     uint64
+	Author   *common.Address `rlp:"nil"`
+	GasLimit uint64
+}
+
+func (self *BlockOverrides) Apply(blk *vm.Block) *vm.Block {
+	if self == nil {
+		return blk
+	}
+	ret := *blk
+	if self.Number != 0 {
+		ret.Number = self.Number
+	}
+	if self.Time != 0 {
+		ret.Time = self.Time
+	}
+	if self.Author != nil {
+		ret.Author = *self.Author
+	}
+	if self.GasLimit != 0 {
+		ret.GasLimit = self.GasLimit
+	}
+	return &ret
+}
+
+type accountOverride struct {
+	*AccountOverride
+	state      map[common.Hash]common.Hash
+	state_diff map[common.Hash]common.Hash
+	code_hash  *common.Hash
+}
+
+// overriddenInput layers state overrides on top of the block state input, so they look like committed state
+type overriddenInput struct {
+	state_evm.Input
+	accounts map[common.Address]*accountOverride
+	codes    map[common.Hash][]byte
+}
+
+// an account with overridden storage needs a storage root, otherwise the storage isn't read at all
+var overridden_storage_root = keccak256.Hash([]byte("overridden storage"))
+
+func newOverriddenInput(in state_evm.Input, overrides StateOverrides) *overriddenInput {
+	self := &overriddenInput{
+		Input:    in,
+		accounts: make(map[common.Address]*accountOverride, len(overrides)),
+		codes:    make(map[common.Hash][]byte),
+	}
+	for i := range overrides {
+		override := &accountOverride{AccountOverride: &overrides[i]}
+		if len(override.Code) != 0 {
+			override.code_hash = keccak256.Hash(override.Code)
+			self.codes[*override.code_hash] = override.Code
+		}
+		if len(override.State) != 0 || override.ReplaceState {
+			override.state = make(map[common.Hash]common.Hash, len(override.State))
+			for _, entry := range override.State {
+				override.state[entry.Key] = entry.Value
+			}
+		}
+		if len(override.StateDiff) != 0 {
+			override.state_diff = make(map[common.Hash]common.Hash, len(override.StateDiff))
+			for _, entry := range override.StateDiff {
+				override.state_diff[entry.Key] = entry.Value
+			}
+		}
+		self.accounts[override.Address] = override
+	}
+	return self
+}
+
+func (self *overriddenInput) GetCode(hash *common.Hash) []byte {
+	if code, present := self.codes[*hash]; present {
+		return code
+	}
+	return self.Input.GetCode(hash)
+}
+
+func (self *overriddenInput) GetAccount(addr *common.Address, cb func(state_db.Account)) {
+	override, present := self.accounts[*addr]
+	if !present {
+		self.Input.GetAccount(addr, cb)
+		return
+	}
+	acc := state_db.Account{Nonce: big.NewInt(0), Balance: big.NewInt(0)}
+	self.Input.GetAccount(addr, func(db_acc state_db.Account) {
+		acc = db_acc
+	})
+	if override.Balance != nil {
+		acc.Balance = override.Balance
+	}
+	if override.Nonce != nil {
+		acc.Nonce = override.Nonce
+	}
+	if override.code_hash != nil {
+		acc.CodeHash, acc.CodeSize = override.code_hash, uint64(len(override.Code))
+	}
+	if override.state != nil || (override.state_diff != nil && acc.StorageRootHash == nil) {
+		acc.StorageRootHash = overridden_storage_root
+	}
+	cb(acc)
+}
+
+func (self *overriddenInput) GetAccountStorage(addr *common.Address, key *common.Hash, cb func([]byte)) {
+	override, present := self.accounts[*addr]
+	if !present {
+		self.Input.GetAccountStorage(addr, key, cb)
+		return
+	}
+	if override.state != nil {
+		storageValue(override.state, key, cb)
+		return
+	}
+	if _, present := override.state_diff[*key]; present {
+		storageValue(override.state_diff, key, cb)
+		return
+	}
+	self.Input.GetAccountStorage(addr, key, cb)
+}
+
+// storageValue passes the value in the db format, where zero values are absent
+func storageValue(storage map[common.Hash]common.Hash, key *common.Hash, cb func([]byte)) {
+	if value := storage[*key]; value != (common.Hash{}) {
+		cb(new(big.Int).SetBytes(value[:]).Bytes())
+	}
+}
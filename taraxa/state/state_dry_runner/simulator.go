@@ -0,0 +1,170 @@
+package state_dry_runner
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/holiman/uint256"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+	dpos "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/dpos/precompiled"
+	slashing "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/slashing/precompiled"
+	contract_storage "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/storage"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/rewards_stats"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_evm"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/bigutil"
+)
+
+// SimulatedBlock is a block of the simulation. Fields which are not overridden are inherited from the previous block,
+// except for the number and the time, which are increased by one
+type SimulatedBlock struct {
+	BlockOverrides *BlockOverrides `rlp:"nil"`
+	Transactions   []SimulatedTransaction
+	// Rewards are distributed after the transactions of the block, if rewards are enabled
+	RewardsStats []rewards_stats.RewardsStats
+}
+
+// SimulatedTransaction is a transaction of the simulation. Decoded nonce can't be nil, so the transactions which are
+// meant to be without nonce set AutoNonce
+type SimulatedTransaction struct {
+	vm.Transaction
+	// AutoNonce makes the transaction get the next nonce of the sender, Nonce is ignored
+	AutoNonce bool
+}
+
+type SimulationOpts struct {
+	// EndBlockCalls makes DPOS and slashing contracts end of block calls to be done after each block
+	EndBlockCalls   bool
+	ReturnStateDiff bool
+}
+
+type SimulatedBlockResult struct {
+	Number      types.BlockNum
+	Time        uint64
+	GasUsed     uint64
+	Results     []vm.ExecutionResult
+	TotalReward *big.Int
+}
+
+// AccountDiff is the state of the account after the simulation. Code is set only if it was changed.
+// Storage contains changed slots of the EVM storage, precompiled contracts storage isn't included
+type AccountDiff struct {
+	Address common.Address
+	Deleted bool
+	Balance *big.Int
+	Nonce   *big.Int
+	Code    []byte
+	Storage []StorageEntry
+}
+
+type SimulationResult struct {
+	Blocks    []SimulatedBlockResult
+	StateDiff []AccountDiff
+}
+
+// Simulate executes the blocks one after another on top of the state of blk.Number. The first simulated block is blk
+// itself, the same way as for Apply. Changes made by the blocks are kept in memory only, so every block sees the
+// changes of the previous ones. Transactions with AutoNonce or without nonce get the next nonce of the sender
+func (self *DryRunner) Simulate(blk *vm.Block, blocks []SimulatedBlock, state_overrides StateOverrides, opts SimulationOpts) (ret SimulationResult) {
+	block_state := state_evm.GetBlockState(self.db, blk.Number, len(blocks))
+	if len(state_overrides) != 0 {
+		block_state.SetInput(newOverriddenInput(block_state.In(), state_overrides))
+	}
+	storage := contract_storage.EVMStateStorage{block_state}
+
+	evm := new(vm.EVM)
+	evm.Init(self.get_block_hash, block_state, vm.DefaultOpts(), self.chain_config.EVMChainConfig, vm.Config{NoBaseFee: true})
+	// contracts are shared by all the blocks, as they keep not yet flushed values in memory
+	var dpos_contract *dpos.Contract
+	var slashing_contract *slashing.Contract
+	if self.dpos_api != nil {
+		dpos_contract = self.dpos_api.NewContract(storage, self.dpos_api.NewDelayedReader(blk.Number, self.get_reader), evm)
+		if self.chain_config.Hardforks.IsOnMagnoliaHardfork(blk.Number) {
+			slashing_contract = self.dpos_api.NewSlashingContract(storage, self.dpos_api.NewSlashingReader(blk.Number, self.get_reader), evm)
+		}
+	}
+
+	curr_blk := *blk
+	for i := range blocks {
+		if i != 0 {
+			curr_blk.Number++
+			curr_blk.Time++
+		}
+		curr_blk = *blocks[i].BlockOverrides.Apply(&curr_blk)
+		rules := self.chain_config.Hardforks.Rules(curr_blk.Number)
+		// precompiled contracts are reset on rules change
+		if evm.SetBlock(&curr_blk, rules) && dpos_contract != nil {
+			dpos_contract.Register(evm.RegisterPrecompiledContract)
+			if slashing_contract != nil {
+				slashing_contract.Register(evm.RegisterPrecompiledContract)
+			}
+		}
+		block_gas_limit := rules.BlockGasLimit(&curr_blk.BlockInfo)
+		blk_ret := SimulatedBlockResult{Number: curr_blk.Number, Time: curr_blk.Time}
+		for j := range blocks[i].Transactions {
+			trx := &blocks[i].Transactions[j].Transaction
+			if blocks[i].Transactions[j].AutoNonce || trx.Nonce == nil {
+				trx.Nonce = bigutil.Add(block_state.GetAccount(&trx.From).GetNonce(), big.NewInt(1))
+			}
+			var res vm.ExecutionResult
+			if block_gas_limit != 0 && trx.Gas > block_gas_limit-blk_ret.GasUsed {
+				res.ConsensusErr = util.NewErrorString(vm.ErrBlockGasLimitReached)
+			} else {
+				var err error
+				res, err = evm.Main(trx)
+				appendRevertReason(&res, err)
+				blk_ret.GasUsed += res.GasUsed
+			}
+			res.CumulativeGasUsed = blk_ret.GasUsed
+			blk_ret.Results = append(blk_ret.Results, res)
+			block_state.FinalizeTransaction()
+		}
+		if dpos_contract != nil {
+			if self.chain_config.RewardsEnabled() && len(blocks[i].RewardsStats) != 0 {
+				total_reward := uint256.NewInt(0)
+				for k := range blocks[i].RewardsStats {
+					total_reward.Add(total_reward, dpos_contract.DistributeRewards(&blocks[i].RewardsStats[k]))
+				}
+				blk_ret.TotalReward = total_reward.ToBig()
+			}
+			if opts.EndBlockCalls {
+				dpos_contract.EndBlockCall(curr_blk.Number)
+				if slashing_contract != nil {
+					slashing_contract.CleanupJailedValidators(curr_blk.Number)
+				}
+			}
+			block_state.FinalizeTransaction()
+		}
+		ret.Blocks = append(ret.Blocks, blk_ret)
+	}
+	if opts.ReturnStateDiff {
+		ret.StateDiff = stateDiff(block_state)
+	}
+	return
+}
+
+func stateDiff(block_state *state_evm.BlockState) (ret []AccountDiff) {
+	block_state.ForEachModifiedAccount(func(acc *state_evm.Account, deleted bool) {
+		diff := AccountDiff{Address: *acc.Address(), Deleted: deleted}
+		if deleted {
+			ret = append(ret, diff)
+			return
+		}
+		diff.Balance, diff.Nonce = acc.GetBalance(), acc.GetNonce()
+		if acc.CodeDirty {
+			diff.Code = acc.GetCode()
+		}
+		for k, v := range acc.StorageDirty {
+			diff.Storage = append(diff.Storage, StorageEntry{common.BytesToHash([]byte(k)), common.BytesToHash(v.Bytes())})
+		}
+		sort.Slice(diff.Storage, func(i, j int) bool {
+			return bytes.Compare(diff.Storage[i].Key[:], diff.Storage[j].Key[:]) < 0
+		})
+		ret = append(ret, diff)
+	})
+	return
+}
@@ -53,7 +53,7 @@ func (self *TraceRunner) Trace(blk *vm.Block, state_trxs *[]vm.Transaction, trxs
 	block_state := state_evm.GetBlockState(self.db, blk_n, len(*trxs))
 
 	var evm vm.EVM
-	evm.Init(self.get_block_hash, block_state, vm.DefaultOpts(), self.chain_config.EVMChainConfig, vm.Config{})
+	evm.Init(self.get_block_hash, block_state, vm.DefaultOpts(), self.chain_config.EVMChainConfig, vm.Config{NoBaseFee: true})
 	evm.SetBlock(blk, self.chain_config.Hardforks.Rules(blk.Number))
 	if self.dpos_api != nil {
 		self.dpos_api.InitAndRegisterAllContracts(contract_storage.EVMStateStorage{block_state}, blk.Number, func(uint64) contract_storage.StorageReader { return block_state }, &evm, evm.RegisterPrecompiledContract)
@@ -61,20 +61,28 @@ func (self *TraceRunner) Trace(blk *vm.Block, state_trxs *[]vm.Transaction, trxs
 
 	for _, trx := range *state_trxs {
 		evm.Main(&trx)
+		block_state.FinalizeTransaction()
 	}
 
 	output := make([]any, len(*trxs))
 	for index, trx := range *trxs {
 		var tracer vm.Tracer
-		if conf != nil {
+		if conf != nil && conf.Tracer != "" {
+			native_tracer, err := vm.NewNativeTracer(conf.Tracer, conf.TracerConfig)
+			if err != nil {
+				panic(err)
+			}
+			tracer = native_tracer
+		} else if conf != nil {
 			tracer = vm.NewOeTracer(conf)
 		} else {
 			// tracer = vm.NewStructLogger(config.LogConfig)
 			tracer = vm.NewStructLogger(nil)
 		}
-		evm.UpdateVmConfig(vm.Config{Debug: true, Tracer: tracer})
+		evm.UpdateVmConfig(vm.Config{Debug: true, Tracer: tracer, NoBaseFee: true})
 
 		ret, _ := evm.Main(&trx)
+		block_state.FinalizeTransaction()
 
 		// Depending on the tracer type, format and return the output
 		switch tracer := tracer.(type) {
@@ -89,6 +97,12 @@ func (self *TraceRunner) Trace(blk *vm.Block, state_trxs *[]vm.Transaction, trxs
 		case *vm.OeTracer:
 			tracer.SetRetCode(ret.CodeRetval)
 			output[index] = tracer.GetResult()
+		case vm.NativeTracer:
+			result, err := tracer.GetResult()
+			if err != nil {
+				panic(err)
+			}
+			output[index] = result
 		default:
 			panic(fmt.Sprintf("bad tracer type %T", tracer))
 		}
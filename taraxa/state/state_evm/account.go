@@ -212,15 +212,21 @@ func (self *Account) IncrementNonce() {
 	})
 }
 
+// SetCode replaces the code of the account, empty code clears it (EIP-7702 delegation reset)
 func (self *Account) SetCode(code []byte) {
 	self.ensure_exists()
 	code_size := len(code)
-	if code_size == 0 {
+	if code_size == 0 && self.CodeSize == 0 {
 		return
 	}
+	code_dirty, code_hash, code_size_prev, code_prev := self.CodeDirty, self.CodeHash, self.CodeSize, self.Code
 	self.register_change(func() {
-		self.CodeDirty, self.CodeHash, self.CodeSize, self.Code = false, nil, 0, nil
+		self.CodeDirty, self.CodeHash, self.CodeSize, self.Code = code_dirty, code_hash, code_size_prev, code_prev
 	})
+	if code_size == 0 {
+		self.CodeDirty, self.CodeHash, self.CodeSize, self.Code = false, nil, 0, nil
+		return
+	}
 	self.CodeDirty, self.CodeHash, self.CodeSize, self.Code = true, keccak256.Hash(code), uint64(code_size), code
 }
 
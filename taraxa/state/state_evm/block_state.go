@@ -9,7 +9,8 @@ import (
 )
 
 type BlockState struct {
-	state TransitionState
+	state    TransitionState
+	accounts Accounts // all the accounts loaded into the state
 }
 
 func (bs *BlockState) Init(opts Opts) {
@@ -46,6 +47,7 @@ func (bs *BlockState) GetAccountConcrete(addr *common.Address) *Account {
 		acc.AccountBody = &AccountBody{AccountChange: AccountChange{Account: db_acc}}
 		acc.loaded_from_db = true
 	})
+	bs.accounts = append(bs.accounts, acc)
 	return acc
 }
 
@@ -53,6 +55,41 @@ func (bs *BlockState) GetAccountConcrete(addr *common.Address) *Account {
 func (bs *BlockState) CommitTransaction(db_writer Output) {
 }
 
+// FinalizeTransaction resets the per transaction data (logs, refund counter, transient storage, access list, created
+// contracts and the journal) to execute the next transaction. Account changes are kept in memory
+func (bs *BlockState) FinalizeTransaction() {
+	bs.state.reverts = bs.state.reverts_original[:0]
+	bs.state.logs = nil
+	bs.state.refund = 0
+	bs.state.transientStorage = nil
+	bs.state.accessList = nil
+	bs.state.created = nil
+}
+
+// ResetTransientState drops the transient storage, which isn't reverted together with the rest of the state
+func (bs *BlockState) ResetTransientState() {
+	bs.state.transientStorage = nil
+}
+
+// ForEachModifiedAccount calls cb for every account which would be updated or deleted in the db
+// if the state was committed. See Account.flush
+func (bs *BlockState) ForEachModifiedAccount(cb func(acc *Account, deleted bool)) {
+	for _, acc := range bs.accounts {
+		if acc.IsNIL() || acc.mod_count == 0 {
+			continue
+		}
+		if acc.suicided || acc.IsEIP161Empty() {
+			if acc.loaded_from_db {
+				cb(acc, true)
+			}
+			continue
+		}
+		if acc.mod_count != acc.times_touched {
+			cb(acc, false)
+		}
+	}
+}
+
 // Commit should do nothing as this state shouldn't be committed
 func (bs *BlockState) Commit() {
 }
@@ -126,3 +163,33 @@ func (bs *BlockState) setTransientState(addr *common.Address, key, value common.
 func (bs *BlockState) GetTransientState(addr *common.Address, key common.Hash) common.Hash {
 	return bs.state.GetTransientState(addr, key)
 }
+
+func (bs *BlockState) PrepareAccessList() {
+	bs.state.PrepareAccessList()
+}
+
+func (bs *BlockState) AddressInAccessList(addr *common.Address) bool {
+	return bs.state.AddressInAccessList(addr)
+}
+
+func (bs *BlockState) SlotInAccessList(addr *common.Address, slot *common.Hash) (addressPresent bool, slotPresent bool) {
+	return bs.state.SlotInAccessList(addr, slot)
+}
+
+func (bs *BlockState) AddAddressToAccessList(addr *common.Address) {
+	bs.state.AddAddressToAccessList(addr)
+}
+
+func (bs *BlockState) AddSlotToAccessList(addr *common.Address, slot *common.Hash) {
+	bs.state.AddSlotToAccessList(addr, slot)
+}
+
+// MarkContractCreated marks the address as created in the current transaction (EIP-6780)
+func (bs *BlockState) MarkContractCreated(addr *common.Address) {
+	bs.state.MarkContractCreated(addr)
+}
+
+// IsContractCreated returns true if the contract was created in the current transaction
+func (bs *BlockState) IsContractCreated(addr *common.Address) bool {
+	return bs.state.IsContractCreated(addr)
+}
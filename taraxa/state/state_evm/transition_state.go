@@ -44,6 +44,8 @@ type TransitionState struct {
 	logs                          []vm.LogRecord
 	refund                        uint64
 	transientStorage              state_db.TransientStorage
+	accessList                    *accessList
+	created                       map[common.Address]struct{} // contracts created in the transaction (EIP-6780)
 }
 
 func (ts *TransitionState) In() Input {
@@ -180,6 +182,8 @@ func (ts *TransitionState) CommitTransaction(db_writer Output) {
 	ts.refund = 0
 	// Reset transient storage
 	ts.transientStorage = nil
+	ts.accessList = nil
+	ts.created = nil
 }
 
 func (ts *TransitionState) Commit() {
@@ -225,3 +229,81 @@ func (ts *TransitionState) GetTransientState(addr *common.Address, key common.Ha
 	ts.initTransientState()
 	return ts.transientStorage.Get(*addr, key)
 }
+
+// PrepareAccessList clears the access list (EIP-2929) before the execution of a transaction.
+func (ts *TransitionState) PrepareAccessList() {
+	ts.accessList = newAccessList()
+}
+
+func (ts *TransitionState) initAccessList() {
+	if ts.accessList == nil {
+		ts.accessList = newAccessList()
+	}
+}
+
+// AddressInAccessList returns true if the given address is in the access list.
+func (ts *TransitionState) AddressInAccessList(addr *common.Address) bool {
+	ts.initAccessList()
+	return ts.accessList.ContainsAddress(*addr)
+}
+
+// SlotInAccessList returns true if the given (address, slot)-tuple is in the access list.
+func (ts *TransitionState) SlotInAccessList(addr *common.Address, slot *common.Hash) (addressPresent bool, slotPresent bool) {
+	ts.initAccessList()
+	return ts.accessList.Contains(*addr, *slot)
+}
+
+// AddAddressToAccessList adds the given address to the access list. The change
+// is journaled, so it is rolled back on revert.
+func (ts *TransitionState) AddAddressToAccessList(addr *common.Address) {
+	ts.initAccessList()
+	if address := *addr; ts.accessList.AddAddress(address) {
+		ts.RegisterChange(func() {
+			ts.accessList.DeleteAddress(address)
+		})
+	}
+}
+
+// AddSlotToAccessList adds the given (address, slot)-tuple to the access list.
+// The changes are journaled, so they are rolled back on revert.
+func (ts *TransitionState) AddSlotToAccessList(addr *common.Address, slot *common.Hash) {
+	ts.initAccessList()
+	address, key := *addr, *slot
+	addr_change, slot_change := ts.accessList.AddSlot(address, key)
+	if addr_change {
+		// In practice, this should not happen, since there is no way to enter the
+		// scope of 'address' without having the 'address' become already added
+		// to the access list (via call-variant, create, etc).
+		// Better safe than sorry, though
+		ts.RegisterChange(func() {
+			ts.accessList.DeleteAddress(address)
+		})
+	}
+	if slot_change {
+		ts.RegisterChange(func() {
+			ts.accessList.DeleteSlot(address, key)
+		})
+	}
+}
+
+// MarkContractCreated marks the address as created in the current transaction (EIP-6780). The change is journaled,
+// so it is rolled back on revert
+func (ts *TransitionState) MarkContractCreated(addr *common.Address) {
+	if ts.IsContractCreated(addr) {
+		return
+	}
+	if ts.created == nil {
+		ts.created = make(map[common.Address]struct{})
+	}
+	address := *addr
+	ts.RegisterChange(func() {
+		delete(ts.created, address)
+	})
+	ts.created[address] = struct{}{}
+}
+
+// IsContractCreated returns true if the contract was created in the current transaction
+func (ts *TransitionState) IsContractCreated(addr *common.Address) bool {
+	_, created := ts.created[*addr]
+	return created
+}
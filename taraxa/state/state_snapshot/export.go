@@ -0,0 +1,128 @@
+package state_snapshot
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_common"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/trie"
+)
+
+type ExportDB interface {
+	state_db.DB
+	GetStateRoot(types.BlockNum) *common.Hash
+}
+
+// UnknownAddressesError lists the hashes of all the accounts with storage which addresses are not resolved
+type UnknownAddressesError struct {
+	AddrHashes []common.Hash
+}
+
+func (self *UnknownAddressesError) Error() string {
+	return fmt.Sprintf("%s: %d accounts", ErrUnknownAddress, len(self.AddrHashes))
+}
+
+func (self *UnknownAddressesError) Unwrap() error {
+	return ErrUnknownAddress
+}
+
+type exporter struct {
+	chunk_writer
+	reader       state_db.ExtendedReader
+	resolve_addr state_db.AddressPreimages
+	chunk        snapshot_chunk
+	size         int
+	unknown      []common.Hash
+}
+
+// Export writes the state of the block blk_n to w. Storage values are stored by the address, so addresses are needed
+// for the accounts with storage. The ones resolve_addr doesn't know are looked up in the preimages of the database,
+// if it stores them. If some addresses are still unknown, the walk continues without writing to report all of them
+// with UnknownAddressesError
+func Export(w io.Writer, db ExportDB, blk_n types.BlockNum, resolve_addr state_db.AddressPreimages) (state_desc state_db.StateDescriptor, err error) {
+	state_root := db.GetStateRoot(blk_n)
+	if state_root == nil {
+		return state_desc, state_db.ErrStateRootUnknown
+	}
+	state_desc = state_db.StateDescriptor{BlockNum: blk_n, StateRoot: *state_root}
+	self := exporter{chunk_writer: chunk_writer{w: bufio.NewWriter(w)}, reader: state_db.GetBlockStateReader(db, blk_n)}
+	self.resolve_addr = resolve_addr
+	if store, ok := db.(state_db.PreimageReader); ok {
+		from_store := state_db.KnownAddresses(nil, store)
+		self.resolve_addr = func(addr_hash *common.Hash) *common.Address {
+			if addr := resolve_addr(addr_hash); addr != nil {
+				return addr
+			}
+			return from_store(addr_hash)
+		}
+	}
+	if _, self.err = self.w.Write(magic); self.err != nil {
+		return state_desc, self.err
+	}
+	self.write(&snapshot_header{version, state_desc})
+	if !state_common.IsEmptyStateRoot(state_root) {
+		trie.Reader{state_db.MainTrieSchema{}}.ForEach(state_db.MainTrieInputAdapter{self.reader}, state_root, true,
+			func(addr_hash *common.Hash, val trie.Value) {
+				if self.err == nil {
+					enc_storage, _ := val.EncodeForTrie()
+					self.export_account(addr_hash, enc_storage)
+				}
+			})
+	}
+	if len(self.unknown) != 0 {
+		return state_desc, &UnknownAddressesError{self.unknown}
+	}
+	self.chunk.Last = true
+	self.flush()
+	if self.err == nil {
+		self.err = self.w.Flush()
+	}
+	return state_desc, self.err
+}
+
+func (self *exporter) export_account(addr_hash *common.Hash, enc_storage []byte) {
+	acc := state_db.DecodeAccountFromTrie(enc_storage)
+	if len(self.unknown) != 0 {
+		// nothing is written after an unknown address, only the rest of them are collected
+		if acc.StorageRootHash != nil && self.resolve_addr(addr_hash) == nil {
+			self.unknown = append(self.unknown, *addr_hash)
+		}
+		return
+	}
+	entry := snapshot_entry{AddrHash: *addr_hash, Account: common.CopyBytes(enc_storage)}
+	if acc.CodeHash != nil {
+		entry.Code = self.reader.GetCode(acc.CodeHash)
+	}
+	self.size += len(entry.Account) + len(entry.Code)
+	if acc.StorageRootHash != nil {
+		if entry.Addr = self.resolve_addr(addr_hash); entry.Addr == nil {
+			self.unknown = append(self.unknown, *addr_hash)
+			return
+		}
+		trie.Reader{state_db.AccountTrieSchema{}}.ForEach(
+			state_db.AccountTrieInputAdapter{entry.Addr, self.reader}, acc.StorageRootHash, true,
+			func(key_hash *common.Hash, val trie.Value) {
+				v, _ := val.EncodeForTrie()
+				entry.Storage = append(entry.Storage, snapshot_slot{*key_hash, common.CopyBytes(v)})
+				if self.size += common.HashLength + len(v); self.size >= chunk_target_size {
+					self.chunk.Entries = append(self.chunk.Entries, entry)
+					self.flush()
+					// the storage continues in the next chunk
+					entry = snapshot_entry{AddrHash: *addr_hash, Addr: entry.Addr}
+				}
+			})
+	}
+	self.chunk.Entries = append(self.chunk.Entries, entry)
+	if self.size >= chunk_target_size {
+		self.flush()
+	}
+}
+
+func (self *exporter) flush() {
+	self.write(&self.chunk)
+	self.chunk, self.size = snapshot_chunk{}, 0
+}
@@ -0,0 +1,140 @@
+package state_snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/crypto"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_common"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/trie"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+)
+
+type ImportDB interface {
+	state_db.DB
+	BeginFirstPendingBlock(types.BlockNum) state_db.PendingBlockState
+}
+
+// flushable_block is implemented by the pending blocks which can write the changes before the commit
+type flushable_block interface {
+	Flush() error
+}
+
+type importer struct {
+	pending   state_db.PendingBlockState
+	main_trie trie.Writer
+	// account which storage is being imported
+	acc         *state_db.Account
+	acc_hash    common.Hash
+	addr        *common.Address
+	acc_storage *trie.Writer
+}
+
+// Import writes the state from the snapshot to the empty database and commits it as the snapshot block. The tries are
+// built from the accounts, code and storage, so the state is committed only if its root is the one of the snapshot.
+// If the database supports it, the writes are flushed after every chunk, so the memory use doesn't depend on the state
+// size. On error the state is not committed, the database should be discarded
+func Import(r io.Reader, db ImportDB) (state_desc state_db.StateDescriptor, err error) {
+	if db.GetLatestState().GetCommittedDescriptor().BlockNum != types.BlockNumberNIL {
+		return state_desc, ErrNotEmptyDB
+	}
+	chunks := chunk_reader{bufio.NewReader(r)}
+	file_magic := make([]byte, len(magic))
+	if _, err = io.ReadFull(chunks.r, file_magic); err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return
+	}
+	var header snapshot_header
+	if err != nil || !bytes.Equal(file_magic, magic) || chunks.read(&header) != nil || header.Version != version {
+		return state_desc, ErrFormat
+	}
+	state_desc = header.StateDesc
+	var self importer
+	self.pending = db.BeginFirstPendingBlock(state_desc.BlockNum)
+	self.main_trie.Init(state_db.MainTrieSchema{}, nil, trie.WriterOpts{})
+	for chunk := (snapshot_chunk{}); !chunk.Last; {
+		chunk = snapshot_chunk{}
+		if err = chunks.read(&chunk); err != nil {
+			return
+		}
+		for i := range chunk.Entries {
+			if err = self.import_entry(&chunk.Entries[i]); err != nil {
+				return
+			}
+		}
+		if flushable, ok := self.pending.(flushable_block); ok {
+			if err = flushable.Flush(); err != nil {
+				return
+			}
+		}
+	}
+	self.finish_account()
+	state_root := state_common.EmptyRLPListHash
+	if root := self.main_trie.Commit(state_db.MainTrieIOAdapter{self.pending}); root != nil {
+		state_root = *root
+	}
+	if state_root != state_desc.StateRoot {
+		return state_desc, ErrStateRootMismatch
+	}
+	if err = db.GetLatestState().Commit(state_root); err != nil {
+		return
+	}
+	_, err = db.GetLatestState().Barrier()
+	return
+}
+
+func (self *importer) import_entry(entry *snapshot_entry) error {
+	if len(entry.Account) != 0 {
+		self.finish_account()
+		acc := state_db.DecodeAccountFromTrie(entry.Account)
+		if acc.CodeHash != nil && *acc.CodeHash != crypto.EmptyBytesKeccak256 {
+			if len(entry.Code) == 0 {
+				return ErrInconsistent
+			}
+			// the code hash is a part of the state root, so the code is verified by the root
+			acc.CodeHash = keccak256.Hash(entry.Code)
+			acc.CodeSize = uint64(len(entry.Code))
+			self.pending.Put(state_db.COL_code, acc.CodeHash, entry.Code)
+		}
+		// storage root is computed from the storage
+		acc.StorageRootHash = nil
+		self.acc, self.acc_hash, self.addr = &acc, entry.AddrHash, entry.Addr
+	} else if self.acc == nil || entry.AddrHash != self.acc_hash {
+		return ErrInconsistent
+	}
+	if len(entry.Storage) == 0 {
+		return nil
+	}
+	if entry.Addr == nil || *keccak256.Hash(entry.Addr[:]) != self.acc_hash {
+		return ErrInconsistent
+	}
+	if self.acc_storage == nil {
+		self.acc_storage = new(trie.Writer).Init(state_db.AccountTrieSchema{}, nil, trie.WriterOpts{})
+		if preimages, ok := self.pending.(state_db.PreimageWriter); ok {
+			preimages.PutPreimage(&self.acc_hash, self.addr[:])
+		}
+	}
+	trie_io := state_db.AccountTrieIOAdapter{self.addr, self.pending}
+	for i := range entry.Storage {
+		slot := &entry.Storage[i]
+		if len(slot.Value) == 0 {
+			return ErrInconsistent
+		}
+		self.acc_storage.Put(trie_io, &slot.KeyHash, state_db.NewAccStorageTrieValue(slot.Value))
+	}
+	return nil
+}
+
+func (self *importer) finish_account() {
+	if self.acc == nil {
+		return
+	}
+	if self.acc_storage != nil {
+		self.acc.StorageRootHash = self.acc_storage.Commit(state_db.AccountTrieIOAdapter{self.addr, self.pending})
+	}
+	self.main_trie.Put(state_db.MainTrieIOAdapter{self.pending}, &self.acc_hash, self.acc)
+	self.acc, self.acc_storage = nil, nil
+}
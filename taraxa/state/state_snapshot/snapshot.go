@@ -0,0 +1,117 @@
+package state_snapshot
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+)
+
+// Snapshot is a portable copy of the state of a block, which doesn't depend on the database layout.
+// The file starts with the magic, followed by the chunks. Chunk is [be32 size][be32 crc32c of the payload][payload],
+// the payload of the first chunk is the snapshot_header and of the rest is the snapshot_chunk. Accounts go in the
+// order of the main trie, storage of an account may continue in the entries of the next chunks
+
+var magic = []byte("taraxa_state_snapshot")
+
+const version = 1
+
+const (
+	// payload size after which the chunk is written
+	chunk_target_size = 4 << 20
+	chunk_max_size    = 2 * chunk_target_size
+)
+
+var crc_table = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	ErrFormat            = errors.New("not a state snapshot or the snapshot version is not supported")
+	ErrChecksum          = errors.New("snapshot chunk checksum mismatch")
+	ErrTruncated         = errors.New("snapshot is truncated")
+	ErrInconsistent      = errors.New("snapshot content is inconsistent")
+	ErrStateRootMismatch = errors.New("state root of the imported state doesn't match the snapshot")
+	ErrUnknownAddress    = errors.New("address of the account with storage is unknown")
+	ErrNotEmptyDB        = errors.New("snapshot can be imported only to an empty database")
+)
+
+type snapshot_header struct {
+	Version   uint64
+	StateDesc state_db.StateDescriptor
+}
+
+type snapshot_chunk struct {
+	Entries []snapshot_entry
+	// Set in the last chunk of the snapshot
+	Last bool
+}
+
+type snapshot_entry struct {
+	AddrHash common.Hash
+	// Storage encoding of the account, empty for the continuation of the storage of the previous entry
+	Account []byte
+	// Set for the accounts with storage, it is a part of the storage keys in the database
+	Addr    *common.Address `rlp:"nil"`
+	Code    []byte
+	Storage []snapshot_slot
+}
+
+type snapshot_slot struct {
+	KeyHash common.Hash
+	Value   []byte
+}
+
+type chunk_writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (self *chunk_writer) write(payload interface{}) {
+	if self.err != nil {
+		return
+	}
+	enc := rlp.MustEncodeToBytes(payload)
+	var frame_header [8]byte
+	binary.BigEndian.PutUint32(frame_header[:4], uint32(len(enc)))
+	binary.BigEndian.PutUint32(frame_header[4:], crc32.Checksum(enc, crc_table))
+	if _, self.err = self.w.Write(frame_header[:]); self.err == nil {
+		_, self.err = self.w.Write(enc)
+	}
+}
+
+type chunk_reader struct {
+	r *bufio.Reader
+}
+
+func (self chunk_reader) read(out interface{}) error {
+	var frame_header [8]byte
+	if _, err := io.ReadFull(self.r, frame_header[:]); err != nil {
+		return read_err(err)
+	}
+	size := binary.BigEndian.Uint32(frame_header[:4])
+	if size > chunk_max_size {
+		return ErrFormat
+	}
+	enc := make([]byte, size)
+	if _, err := io.ReadFull(self.r, enc); err != nil {
+		return read_err(err)
+	}
+	if crc32.Checksum(enc, crc_table) != binary.BigEndian.Uint32(frame_header[4:]) {
+		return ErrChecksum
+	}
+	if rlp.DecodeBytes(enc, out) != nil {
+		return ErrFormat
+	}
+	return nil
+}
+
+func read_err(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncated
+	}
+	return err
+}
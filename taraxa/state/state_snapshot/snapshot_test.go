@@ -0,0 +1,209 @@
+package state_snapshot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/types"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db_memory"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_db_rocksdb"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_evm"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_transition"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/tests"
+)
+
+type test_state struct {
+	db       *state_db_memory.DB
+	addrs    []common.Address
+	contract map[common.Address]bool
+	keys     []common.Hash
+}
+
+// commit writes the block which changes the balance of every account and the storage of every contract
+func (self *test_state) commit(rnd *rand.Rand) {
+	latest_state := self.db.GetLatestState()
+	state_desc := latest_state.GetCommittedDescriptor()
+	var sink state_transition.TrieSink
+	sink.Init(&state_desc.StateRoot, state_transition.TrieSinkOpts{})
+	defer sink.Close()
+	pending := latest_state.BeginPendingBlock()
+	reader := state_db.ExtendedReader{self.db.GetBlockStateReader(state_desc.BlockNum)}
+	sink.SetIO(pending)
+	for i := range self.addrs {
+		addr := &self.addrs[i]
+		change := state_evm.AccountChange{Account: state_db.Account{Nonce: big.NewInt(1)}}
+		if state_desc.BlockNum != types.BlockNumberNIL {
+			reader.GetAccount(addr, func(acc state_db.Account) { change.Account = acc })
+		}
+		change.Balance = big.NewInt(rnd.Int63())
+		if self.contract[*addr] {
+			if change.CodeHash == nil {
+				change.Code = make([]byte, 1+rnd.Intn(100))
+				rnd.Read(change.Code)
+				change.CodeHash, change.CodeSize, change.CodeDirty = keccak256.Hash(change.Code), uint64(len(change.Code)), true
+			}
+			change.RawStorageDirty = make(state_evm.RawStorage)
+			for _, k := range self.keys[:rnd.Intn(len(self.keys))] {
+				// both the values stored in the trie and separately
+				v := make([]byte, 1+rnd.Intn(32))
+				rnd.Read(v)
+				change.RawStorageDirty[k] = v
+			}
+		}
+		mutation := sink.StartMutation(addr)
+		mutation.Update(change)
+		mutation.Commit()
+	}
+	latest_state.Commit(sink.Commit())
+}
+
+func (self *test_state) check_equal(t *testing.T, other state_db.DB, blk_n types.BlockNum) {
+	expected, actual := state_db.GetBlockStateReader(self.db, blk_n), state_db.GetBlockStateReader(other, blk_n)
+	for i := range self.addrs {
+		addr := &self.addrs[i]
+		var expected_acc, actual_acc []byte
+		expected.GetRawAccount(addr, func(v []byte) { expected_acc = common.CopyBytes(v) })
+		actual.GetRawAccount(addr, func(v []byte) { actual_acc = common.CopyBytes(v) })
+		if !bytes.Equal(expected_acc, actual_acc) {
+			t.Fatalf("account %x: %x, expected %x", addr, actual_acc, expected_acc)
+		}
+		if code := actual.GetCodeByAddress(addr); !bytes.Equal(code, expected.GetCodeByAddress(addr)) {
+			t.Fatalf("account %x: wrong code %x", addr, code)
+		}
+		for j := range self.keys {
+			var expected_v, actual_v []byte
+			expected.GetAccountStorage(addr, &self.keys[j], func(v []byte) { expected_v = common.CopyBytes(v) })
+			actual.GetAccountStorage(addr, &self.keys[j], func(v []byte) { actual_v = common.CopyBytes(v) })
+			if !bytes.Equal(expected_v, actual_v) {
+				t.Fatalf("account %x key %x: %x, expected %x", addr, self.keys[j], actual_v, expected_v)
+			}
+		}
+	}
+}
+
+type preimage_db struct {
+	*state_db_memory.DB
+	preimages map[common.Hash][]byte
+}
+
+func (self preimage_db) GetPreimage(hash *common.Hash) []byte {
+	return self.preimages[*hash]
+}
+
+func TestExportImport(t *testing.T) {
+	tc := tests.NewTestCtx(t)
+	defer tc.Close()
+	rnd := rand.New(rand.NewSource(1))
+	state := test_state{db: new(state_db_memory.DB).Init(), contract: make(map[common.Address]bool)}
+	for i := 0; i < 100; i++ {
+		var addr common.Address
+		rnd.Read(addr[:])
+		state.addrs = append(state.addrs, addr)
+		state.contract[addr] = i%10 == 0
+	}
+	for i := 0; i < 50; i++ {
+		var k common.Hash
+		rnd.Read(k[:])
+		state.keys = append(state.keys, k)
+	}
+	state.commit(rnd)
+	state.commit(rnd)
+
+	// the history is exported as well as the latest state
+	for blk_n := types.BlockNum(0); blk_n < 2; blk_n++ {
+		var snapshot bytes.Buffer
+		exported, err := Export(&snapshot, state.db, blk_n, state_db.KnownAddresses(state.addrs, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exported.StateRoot != *state.db.GetStateRoot(blk_n) {
+			t.Fatalf("block %d: wrong exported state root", blk_n)
+		}
+		db := new(state_db_memory.DB).Init()
+		imported, err := Import(bytes.NewReader(snapshot.Bytes()), db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported != exported || db.GetLatestState().GetCommittedDescriptor() != exported {
+			t.Fatalf("block %d: imported %v, exported %v", blk_n, imported, exported)
+		}
+		state.check_equal(t, db, blk_n)
+		if _, err := Import(bytes.NewReader(snapshot.Bytes()), db); err != ErrNotEmptyDB {
+			t.Fatalf("import to not empty db: %v", err)
+		}
+
+		// the writes are flushed before the commit
+		rocksdb := new(state_db_rocksdb.DB).Init(state_db_rocksdb.Opts{Path: fmt.Sprintf("%s/%d", tc.DataDir(), blk_n)})
+		if imported, err := Import(bytes.NewReader(snapshot.Bytes()), rocksdb); err != nil || imported != exported {
+			t.Fatalf("block %d: import to rocksdb %v: %v", blk_n, imported, err)
+		}
+		state.check_equal(t, rocksdb, blk_n)
+		rocksdb.Close()
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	state := test_state{db: new(state_db_memory.DB).Init(), contract: make(map[common.Address]bool)}
+	state.addrs = []common.Address{{1}, {2}}
+	state.contract[common.Address{2}] = true
+	state.keys = []common.Hash{{1}, {2}, {3}}
+	state.commit(rnd)
+
+	_, err := Export(new(bytes.Buffer), state.db, 0, state_db.KnownAddresses(state.addrs[:1], nil))
+	if unknown, ok := err.(*UnknownAddressesError); !ok || !errors.Is(err, ErrUnknownAddress) ||
+		len(unknown.AddrHashes) != 1 || unknown.AddrHashes[0] != *keccak256.Hash(state.addrs[1][:]) {
+		t.Fatalf("export without the address of the contract: %v", err)
+	}
+	// the address is found in the preimages of the db
+	preimages := map[common.Hash][]byte{*keccak256.Hash(state.addrs[1][:]): state.addrs[1][:]}
+	if _, err := Export(new(bytes.Buffer), preimage_db{state.db, preimages}, 0, state_db.KnownAddresses(nil, nil)); err != nil {
+		t.Fatalf("export with the address in the preimages: %v", err)
+	}
+	var snapshot bytes.Buffer
+	if _, err := Export(&snapshot, state.db, 0, state_db.KnownAddresses(state.addrs, nil)); err != nil {
+		t.Fatal(err)
+	}
+	import_err := func(snapshot []byte) error {
+		_, err := Import(bytes.NewReader(snapshot), new(state_db_memory.DB).Init())
+		return err
+	}
+	if err := import_err([]byte("not a snapshot")); err != ErrFormat {
+		t.Fatalf("import of not a snapshot: %v", err)
+	}
+	if err := import_err(snapshot.Bytes()[:snapshot.Len()-1]); err != ErrTruncated {
+		t.Fatalf("import of the truncated snapshot: %v", err)
+	}
+	corrupted := common.CopyBytes(snapshot.Bytes())
+	corrupted[len(corrupted)-10]++
+	if err := import_err(corrupted); err != ErrChecksum {
+		t.Fatalf("import of the corrupted snapshot: %v", err)
+	}
+
+	// header with a wrong state root and a valid checksum
+	header_start := len(magic)
+	header_end := header_start + 8 + int(binary.BigEndian.Uint32(snapshot.Bytes()[header_start:]))
+	var header snapshot_header
+	rlp.MustDecodeBytes(snapshot.Bytes()[header_start+8:header_end], &header)
+	header.StateDesc.StateRoot[0]++
+	var forged bytes.Buffer
+	forged.Write(magic)
+	enc := rlp.MustEncodeToBytes(&header)
+	forged.Write(binary.BigEndian.AppendUint32(nil, uint32(len(enc))))
+	forged.Write(binary.BigEndian.AppendUint32(nil, crc32.Checksum(enc, crc_table)))
+	forged.Write(enc)
+	forged.Write(snapshot.Bytes()[header_end:])
+	if err := import_err(forged.Bytes()); err != ErrStateRootMismatch {
+		t.Fatalf("import of the snapshot with a wrong state root: %v", err)
+	}
+}
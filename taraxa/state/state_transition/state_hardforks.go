@@ -1,36 +1,39 @@
 package state_transition
 
 import (
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
 	dpos "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/dpos/precompiled"
-	dpos_sol "github.com/Taraxa-project/taraxa-evm/taraxa/state/contracts/dpos/solidity"
-	"github.com/Taraxa-project/taraxa-evm/taraxa/state/state_transition/op_stack"
 )
 
-func (st *StateTransition) applyHFChanges() {
-	blk_n := st.BlockNumber()
+// hardfork_state is the state the hardfork migrations are applied to
+type hardfork_state struct {
+	st *StateTransition
+}
+
+func (self hardfork_state) GetAccount(addr *common.Address) vm.StateAccount {
+	return self.st.state.GetAccount(addr)
+}
 
+func (self hardfork_state) DPOSContractAddress() *common.Address {
+	if self.st.dpos_contract == nil {
+		return nil
+	}
+	return dpos.ContractAddress()
+}
+
+// registerContracts registers the DPOS and slashing contracts, it is needed on every rules change as the EVM resets
+// the precompiled contracts
+func (st *StateTransition) registerContracts() {
 	if st.dpos_contract != nil {
 		st.dpos_contract.Register(st.evm.RegisterPrecompiledContract)
-		if st.chain_config.Hardforks.IsOnAspenHardforkPartOne(blk_n) {
-			acc := st.state.GetAccount(dpos.ContractAddress())
-			if acc.GetCodeSize() == 0 {
-				acc.SetCode(dpos_sol.AspenDposImplBytecode)
-			}
-		}
-		if st.chain_config.Hardforks.IsCornusHardfork(blk_n) {
-			acc := st.state.GetAccount(dpos.ContractAddress())
-			acc.SetCode(dpos_sol.CornusDposImplBytecode)
-		}
 	}
-
-	if st.slashing_contract != nil && st.chain_config.Hardforks.IsOnMagnoliaHardfork(blk_n) {
+	if st.slashing_contract != nil && st.chain_config.Hardforks.IsOnMagnoliaHardfork(st.BlockNumber()) {
 		st.slashing_contract.Register(st.evm.RegisterPrecompiledContract)
 	}
+}
 
-	if st.chain_config.Hardforks.IsCornusHardfork(blk_n) {
-		for acc, byteCode := range op_stack.OpPrecompiles {
-			acc := st.state.GetAccount(&acc)
-			acc.SetCode(byteCode)
-		}
-	}
+// applyHFChanges applies the state migrations of the hardforks starting at the current block
+func (st *StateTransition) applyHFChanges() {
+	st.chain_config.Hardforks.ApplyMigrations(st.BlockNumber(), hardfork_state{st})
 }
@@ -1,6 +1,8 @@
 package state_transition
 
 import (
+	"math/big"
+
 	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/core/types"
 	"github.com/Taraxa-project/taraxa-evm/core/vm"
@@ -32,6 +34,8 @@ type StateTransition struct {
 	get_slashing_reader func(types.BlockNum) slashing.Reader
 	new_chain_config    *chain_config.ChainConfig
 	LastBlockNum        uint64
+	block_gas_used      uint64
+	block_gas_limit     uint64 // 0 means no limit
 }
 
 type Opts struct {
@@ -66,9 +70,13 @@ func (st *StateTransition) Init(
 		for addr, balance := range st.chain_config.GenesisBalances {
 			st.state.GetAccount(&addr).AddBalance(balance)
 		}
+		for addr, acc := range st.chain_config.GenesisAlloc {
+			st.apply_genesis_account(&addr, &acc)
+		}
 		if st.dpos_contract != nil {
 			util.PanicIfNotNil(st.dpos_contract.ApplyGenesis(st.state.GetAccount))
 		}
+		st.registerContracts()
 		st.applyHFChanges()
 		st.evm_state_checkpoint()
 		st.Commit()
@@ -78,6 +86,24 @@ func (st *StateTransition) Init(
 	return st
 }
 
+func (st *StateTransition) apply_genesis_account(addr *common.Address, acc *chain_config.GenesisAccount) {
+	account := st.state.GetAccount(addr)
+	if acc.Balance != nil {
+		account.AddBalance(acc.Balance)
+	}
+	if acc.Nonce != 0 {
+		account.SetNonce(new(big.Int).SetUint64(acc.Nonce))
+	}
+	if len(acc.Code) != 0 {
+		account.SetCode(acc.Code)
+	}
+	for k, v := range acc.Storage {
+		if v != (common.Hash{}) {
+			account.SetState(k.Big(), v.Big())
+		}
+	}
+}
+
 func (st *StateTransition) UpdateConfig(cfg *chain_config.ChainConfig) {
 	st.new_chain_config = cfg
 }
@@ -103,18 +129,57 @@ func (st *StateTransition) BlockNumber() types.BlockNum {
 func (st *StateTransition) BeginBlock(blk_info *vm.BlockInfo) {
 	st.begin_block()
 	blk_n := st.BlockNumber()
-	rules_changed := st.evm.SetBlock(&vm.Block{Number: blk_n, BlockInfo: *blk_info}, st.chain_config.Hardforks.Rules(blk_n))
-	if rules_changed {
-		st.applyHFChanges()
+	if st.evm.SetBlock(&vm.Block{Number: blk_n, BlockInfo: *blk_info}, st.chain_config.Hardforks.Rules(blk_n)) {
+		st.registerContracts()
 	}
+	st.applyHFChanges()
+	rules := st.evm.GetRules()
+	st.block_gas_used, st.block_gas_limit = 0, rules.BlockGasLimit(blk_info)
 }
 
 func (st *StateTransition) ExecuteTransaction(tx *vm.Transaction) (ret vm.ExecutionResult) {
+	// Transaction is rejected without execution if its gas limit doesn't fit into the rest of the block gas limit
+	if st.block_gas_limit != 0 && tx.Gas > st.block_gas_limit-st.block_gas_used {
+		ret.ConsensusErr = util.NewErrorString(vm.ErrBlockGasLimitReached)
+		ret.CumulativeGasUsed = st.block_gas_used
+		return
+	}
 	ret, _ = st.evm.Main(tx)
+	if st.evm.GetRules().IsLantana {
+		st.collectBaseFee(tx, &ret)
+	}
 	st.evm_state_checkpoint()
+	st.block_gas_used += ret.GasUsed
+	ret.CumulativeGasUsed = st.block_gas_used
 	return
 }
 
+// BlockGasUsed returns the gas used by the transactions executed in the current block so far
+func (st *StateTransition) BlockGasUsed() uint64 {
+	return st.block_gas_used
+}
+
+// collectBaseFee sends the base fee part of the fee paid by the sender to the configured sink. If there is no sink it is
+// just burned (EIP-1559). The rest of the fee (EffectiveTip per gas) is the validators' reward, added to the block author
+// balance by the caller before Magnolia HF. Since it the client computes the fees rewards of the validators, so the sink
+// is rejected by ChainConfig.Validate
+func (st *StateTransition) collectBaseFee(tx *vm.Transaction, res *vm.ExecutionResult) {
+	sink := &st.chain_config.Hardforks.LantanaHf.BaseFeeSink
+	base_fee := st.evm.GetBlock().BaseFee
+	if *sink == common.ZeroAddress || base_fee == nil || base_fee.Sign() == 0 || res.GasUsed == 0 {
+		return
+	}
+	// System transactions and zero price calls don't pay the base fee
+	if tx.From == common.ZeroAddress || res.GasPrice == nil || res.GasPrice.Sign() == 0 {
+		return
+	}
+	paid := base_fee
+	if res.GasPrice.Cmp(base_fee) < 0 {
+		paid = res.GasPrice
+	}
+	st.state.GetAccount(sink).AddBalance(new(big.Int).Mul(paid, new(big.Int).SetUint64(res.GasUsed)))
+}
+
 func (st *StateTransition) GetChainConfig() (ret *chain_config.ChainConfig) {
 	ret = st.chain_config
 	return
@@ -159,7 +224,7 @@ func (st *StateTransition) Commit() (state_root common.Hash) {
 		st.PrepareCommit()
 	}
 	state_root, st.pending_state_root = st.pending_state_root, common.ZeroHash
-	util.PanicIfNotNil(st.latest_state.Commit(state_root)) // TODO move out of here, this should be async
+	util.PanicIfNotNil(st.latest_state.Commit(state_root))
 	if st.dpos_contract != nil {
 		st.dpos_contract.CommitCall(st.get_dpos_reader(st.evm.GetBlock().Number))
 	}
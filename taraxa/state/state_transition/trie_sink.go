@@ -19,6 +19,7 @@ type TrieSink struct {
 	thread_main_trie_write     goroutines.GoroutineGroup
 	threads_account_trie_write goroutines.SequentialTaskGroupExecutor
 	io                         state_db.ReadWriter
+	preimages                  state_db.PreimageWriter
 	main_trie_writer           trie.Writer
 }
 type TrieSinkOpts struct {
@@ -42,6 +43,7 @@ func (self *TrieSink) Close() {
 
 func (self *TrieSink) SetIO(io state_db.ReadWriter) {
 	self.io = io
+	self.preimages, _ = io.(state_db.PreimageWriter)
 }
 
 func (self *TrieSink) StartMutation(addr *common.Address) state_evm.AccountMutation {
@@ -71,14 +73,18 @@ type TrieSinkAccountMutation struct {
 }
 
 func (self *TrieSinkAccountMutation) Update(upd state_evm.AccountChange) {
-	io := self.host.io
+	io, preimages := self.host.io, self.host.preimages
 	if upd.CodeDirty {
 		io.Put(state_db.COL_code, upd.CodeHash, upd.Code)
 	}
 	if !self.pending {
 		self.pending = true
+		addr_hash := keccak256.Hash(self.addr[:])
+		if preimages != nil {
+			preimages.PutPreimage(addr_hash, self.addr[:])
+		}
 		self.host.thread_main_trie_write.Submit(func() {
-			self.host.main_trie_writer.Put(state_db.MainTrieIOAdapter{io}, keccak256.Hash(self.addr[:]), self)
+			self.host.main_trie_writer.Put(state_db.MainTrieIOAdapter{io}, addr_hash, self)
 		})
 	}
 	self.thread.Submit(func() {
@@ -93,10 +99,14 @@ func (self *TrieSinkAccountMutation) Update(upd state_evm.AccountChange) {
 		var big_conv bigconv.BigConv
 		trie_io := state_db.AccountTrieIOAdapter{self.addr, io}
 		for k, v := range upd.StorageDirty {
-			if k_h := keccak256.Hash(big_conv.ToHash(k.Int())[:]); v.Sign() == 0 {
+			k_bytes := big_conv.ToHash(k.Int())[:]
+			if k_h := keccak256.Hash(k_bytes); v.Sign() == 0 {
 				self.trie_writer.Delete(trie_io, k_h)
 			} else {
 				self.trie_writer.Put(trie_io, k_h, state_db.NewAccStorageTrieValue(v.Bytes()))
+				if preimages != nil {
+					preimages.PutPreimage(k_h, common.CopyBytes(k_bytes))
+				}
 			}
 		}
 		for k, v := range upd.RawStorageDirty {
@@ -104,6 +114,9 @@ func (self *TrieSinkAccountMutation) Update(upd state_evm.AccountChange) {
 				self.trie_writer.Delete(trie_io, k_h)
 			} else {
 				self.trie_writer.Put(trie_io, k_h, state_db.NewAccStorageTrieValue(v))
+				if preimages != nil {
+					preimages.PutPreimage(k_h, common.CopyBytes(k[:]))
+				}
 			}
 		}
 	})
@@ -0,0 +1,140 @@
+package state
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/core/vm"
+	"github.com/Taraxa-project/taraxa-evm/crypto"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/state/chain_config"
+)
+
+// native_tracer_test traces the transactions of the first block with the native tracer
+type native_tracer_test struct {
+	*hardfork_test
+	caller, emitter, reverter common.Address
+}
+
+func new_native_tracer_test(t *testing.T) *native_tracer_test {
+	// emitter logs 0x2a with the topic 7, reverter logs and reverts with Panic(1). The caller calls both of them,
+	// creates an empty contract with CREATE2 and stores 1 to the slot 0
+	self := &native_tracer_test{caller: common.Address{0: 0xc0, 19: 1}, emitter: common.Address{0: 0xc0, 19: 2}, reverter: common.Address{0: 0xc0, 19: 3}}
+	alloc := chain_config.GenesisAlloc{
+		self.caller: {Balance: big.NewInt(0), Code: common.FromHex(
+			"0x6000600060006000600073c0000000000000000000000000000000000000025af150" +
+				"6000600060006000600073c0000000000000000000000000000000000000035af150" +
+				"6000600060006000f550" + "600160005500")},
+		self.emitter:  {Balance: big.NewInt(0), Code: common.FromHex("0x602a600052600760206000a100")},
+		self.reverter: {Balance: big.NewInt(0), Code: common.FromHex("0x600760006000a1634e487b7160e01b600052600160045260246000fd")},
+	}
+	self.hardfork_test = new_hardfork_test(t, alloc, func(*chain_config.HardforksConfig) {})
+	return self
+}
+
+func (self *native_tracer_test) trace(tracer string, tracer_config string, trxs ...vm.Transaction) (ret []json.RawMessage) {
+	blk := &vm.Block{Number: 1, BlockInfo: vm.BlockInfo{GasLimit: 100_000_000, Difficulty: big.NewInt(0)}}
+	conf := &vm.TracingConfig{Tracer: tracer, TracerConfig: []byte(tracer_config)}
+	if err := json.Unmarshal(self.api.Trace(blk, &[]vm.Transaction{}, &trxs, conf), &ret); err != nil {
+		self.t.Fatal(err)
+	}
+	if len(ret) != len(trxs) {
+		self.t.Fatalf("traces: %s", ret)
+	}
+	return
+}
+
+func TestCallTracer(t *testing.T) {
+	test := new_native_tracer_test(t)
+	defer test.close()
+
+	traces := test.trace(vm.CallTracerName, `{"withLog":true}`, test.trx(&test.emitter, nil, nil))
+	expected := `{"from":"0x0000000000000000000000000000000000007e57","gas":"0xf4240","gasUsed":"0x560b",` +
+		`"to":"0xc000000000000000000000000000000000000002","input":"0x","logs":[{"address":"0xc000000000000000000000000000000000000002",` +
+		`"topics":["0x0000000000000000000000000000000000000000000000000000000000000007"],` +
+		`"data":"0x000000000000000000000000000000000000000000000000000000000000002a","position":"0x0"}],"value":"0x0","type":"CALL"}`
+	if string(traces[0]) != expected {
+		t.Fatalf("trace %s", traces[0])
+	}
+
+	type frame struct {
+		Type, Error, RevertReason string
+		To                        *common.Address
+		Calls                     []frame
+		Logs                      []json.RawMessage
+	}
+	var top frame
+	if err := json.Unmarshal(test.trace(vm.CallTracerName, `{"withLog":true}`, test.trx(&test.caller, nil, nil))[0], &top); err != nil {
+		t.Fatal(err)
+	}
+	if top.Type != "CALL" || top.Error != "" || len(top.Calls) != 3 {
+		t.Fatalf("top call %+v", top)
+	}
+	if call := top.Calls[0]; call.Type != "CALL" || *call.To != test.emitter || len(call.Logs) != 1 {
+		t.Fatalf("emitter call %+v", call)
+	}
+	if call := top.Calls[1]; call.Type != "CALL" || call.Error != vm.ErrExecutionReverted.Error() || call.RevertReason != "assert(false)" || len(call.Logs) != 0 {
+		t.Fatalf("reverter call %+v", call)
+	}
+	created := crypto.CreateAddress2(&test.caller, &common.Hash{}, crypto.EmptyBytesKeccak256[:])
+	if call := top.Calls[2]; call.Type != "CREATE2" || *call.To != created {
+		t.Fatalf("create2 call %+v", call)
+	}
+
+	var only_top frame
+	if err := json.Unmarshal(test.trace(vm.CallTracerName, `{"onlyTopCall":true}`, test.trx(&test.caller, nil, nil))[0], &only_top); err != nil {
+		t.Fatal(err)
+	}
+	if only_top.Type != "CALL" || len(only_top.Calls) != 0 {
+		t.Fatalf("subcalls are traced with onlyTopCall: %+v", only_top)
+	}
+}
+
+func TestPrestateTracer(t *testing.T) {
+	test := new_native_tracer_test(t)
+	defer test.close()
+	created := crypto.CreateAddress2(&test.caller, &common.Hash{}, crypto.EmptyBytesKeccak256[:])
+
+	type account struct {
+		Balance *string
+		Code    string
+		Nonce   uint64
+		Storage map[common.Hash]common.Hash
+	}
+	var pre map[common.Address]account
+	if err := json.Unmarshal(test.trace(vm.PrestateTracerName, "", test.trx(&test.caller, nil, nil))[0], &pre); err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []common.Address{test.sender, test.caller, test.emitter, test.reverter, {}} {
+		if _, present := pre[addr]; !present {
+			t.Fatalf("%s is not in the prestate %+v", addr.Hex(), pre)
+		}
+	}
+	if _, present := pre[created]; present {
+		t.Fatalf("created contract is in the prestate %+v", pre)
+	}
+	if acc := pre[test.caller]; acc.Balance == nil || *acc.Balance != "0x0" || len(acc.Code) == 0 || len(acc.Storage) != 1 || acc.Storage[common.Hash{}] != (common.Hash{}) {
+		t.Fatalf("caller prestate %+v", acc)
+	}
+
+	var diff struct{ Pre, Post map[common.Address]account }
+	if err := json.Unmarshal(test.trace(vm.PrestateTracerName, `{"diffMode":true}`, test.trx(&test.caller, nil, nil))[0], &diff); err != nil {
+		t.Fatal(err)
+	}
+	if _, present := diff.Pre[test.emitter]; present {
+		t.Fatalf("unmodified account is in the diff %+v", diff)
+	}
+	if acc := diff.Post[test.caller]; acc.Nonce != 1 || acc.Balance != nil || acc.Storage[common.Hash{}] != common.BytesToHash([]byte{1}) {
+		t.Fatalf("caller post state %+v", acc)
+	}
+	if acc := diff.Pre[test.caller]; len(acc.Storage) != 0 {
+		t.Fatalf("caller pre state %+v", acc)
+	}
+	if acc, present := diff.Post[created]; !present || acc.Nonce != 1 {
+		t.Fatalf("created contract post state %+v", diff.Post)
+	}
+	if _, present := diff.Post[test.sender]; !present {
+		t.Fatalf("sender nonce change is not in the diff %+v", diff.Post)
+	}
+}
@@ -1,6 +1,7 @@
 package trie
 
 import (
+	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/rlp"
 )
 
@@ -8,6 +9,38 @@ type commit_context struct {
 	hex_key_compact_tmp hex_key_compact
 	enc_hash            hash_encoder
 	enc_storage         rlp.Encoder
+	parallel            bool
+}
+
+// write_buffer keeps the writes of a subtrie committed concurrently
+type write_buffer struct {
+	Input
+	writes []buffered_write
+}
+
+type buffered_write struct {
+	key     *common.Hash
+	value   []byte
+	is_node bool
+}
+
+func (self *write_buffer) PutValue(key *common.Hash, v []byte) {
+	self.writes = append(self.writes, buffered_write{key, v, false})
+}
+
+func (self *write_buffer) PutNode(node_hash *common.Hash, node []byte) {
+	self.writes = append(self.writes, buffered_write{node_hash, node, true})
+}
+
+func (self *write_buffer) flush(db_tx IO) {
+	for _, w := range self.writes {
+		if w.is_node {
+			db_tx.PutNode(w.key, w.value)
+		} else {
+			db_tx.PutValue(w.key, w.value)
+		}
+	}
+	self.writes = nil
 }
 
 func (self *commit_context) Reset() {
@@ -30,6 +30,12 @@ func (self *hash_encoder) AppendString(str []byte) {
 	}
 }
 
+func (self *hash_encoder) AppendRaw(b []byte) {
+	if !self.disabled {
+		self.encoder.AppendRaw(b...)
+	}
+}
+
 func (self *hash_encoder) ListStart() int {
 	if !self.disabled {
 		return self.encoder.ListStart()
@@ -0,0 +1,179 @@
+package trie
+
+import (
+	"errors"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util/keccak256"
+)
+
+var ErrProofNodeMissing = errors.New("proof node is missing")
+var ErrProofNodeInvalid = errors.New("proof node is invalid")
+
+var empty_root = keccak256.Hash([]byte{rlp.EmptyString})
+
+// Prove collects the nodes on the path to the key, starting from the root. Nodes are in the standard MPT encoding
+// (the one their hashes are computed from), which differs from the encoding they are stored with.
+// Value is nil if the key is absent, then the proof proves the absence
+func (self Reader) Prove(db_tx Input, root_hash *common.Hash, key *common.Hash) (proof [][]byte, value Value) {
+	if root_hash == nil || *root_hash == *empty_root {
+		return
+	}
+	var kbuf hex_key
+	keybytes_to_hex(key[:], kbuf[:])
+	var n node = (*node_hash)(root_hash)
+	for pos := 0; n != nil; {
+		if hash, is_hash := n.(*node_hash); is_hash {
+			// decoding appends to the prefix, so it must not share the key buffer
+			n, _ = self.resolve(db_tx, hash, common.CopyBytes(kbuf[:pos]))
+		}
+		// nodes shorter than a hash are embedded into the parent, except for the root
+		if enc := self.hash_encoding(db_tx, n, kbuf[:pos]); pos == 0 || len(enc) >= common.HashLength {
+			proof = append(proof, enc)
+		}
+		switch curr := n.(type) {
+		case *short_node:
+			if prefixLen(kbuf[pos:], curr.key_part) != len(curr.key_part) {
+				return
+			}
+			pos += len(curr.key_part)
+			val_n, has_val := curr.val.(value_node)
+			if !has_val {
+				n = curr.val
+				continue
+			}
+			if val_n == nil_val_node {
+				val_n = self.resolve_val_n(db_tx, key)
+			}
+			value = val_n.val
+			return
+		case *full_node:
+			n = curr.children[kbuf[pos]]
+			pos++
+		default:
+			panic("impossible")
+		}
+	}
+	return
+}
+
+// hash_encoding encodes the node the same way as the Writer does it for hashing
+func (self Reader) hash_encoding(db_tx Input, n node, key_prefix []byte) []byte {
+	var enc rlp.Encoder
+	list_start := enc.ListStart()
+	switch n := n.(type) {
+	case *short_node:
+		var compact_buf hex_key_compact
+		enc.AppendString(hex_to_compact(n.key_part, &compact_buf))
+		key_extended := append(common.CopyBytes(key_prefix), n.key_part...)
+		if val_n, has_val := n.val.(value_node); has_val {
+			if val_n == nil_val_node {
+				val_n = self.resolve_val_n_by_hex_k(db_tx, key_extended)
+			}
+			_, enc_hash := val_n.val.EncodeForTrie()
+			enc.AppendString(enc_hash)
+		} else {
+			self.append_hash_ref(db_tx, &enc, n.val, key_extended)
+		}
+	case *full_node:
+		for i := byte(0); i < full_node_child_cnt; i++ {
+			self.append_hash_ref(db_tx, &enc, n.children[i], append(common.CopyBytes(key_prefix), i))
+		}
+		enc.AppendString(nil)
+	default:
+		panic("impossible")
+	}
+	enc.ListEnd(list_start)
+	return enc.ToBytes(list_start)
+}
+
+// append_hash_ref appends the child the way it is referenced from the parent: nodes which encoding is shorter
+// than a hash are embedded, the rest are referenced by hash
+func (self Reader) append_hash_ref(db_tx Input, enc *rlp.Encoder, n node, key_prefix []byte) {
+	if n == nil {
+		enc.AppendString(nil)
+		return
+	}
+	if hash := n.get_hash(); hash != nil {
+		enc.AppendString(hash[:])
+		return
+	}
+	if child_enc := self.hash_encoding(db_tx, n, key_prefix); len(child_enc) < common.HashLength {
+		enc.AppendRaw(child_enc...)
+	} else {
+		enc.AppendString(keccak256.Hash(child_enc)[:])
+	}
+}
+
+// VerifyProof checks the proof made by Prove against the root hash. It returns the value of the key in the
+// hash encoding, or nil if the proof proves that the key is absent
+func VerifyProof(root_hash *common.Hash, key *common.Hash, proof [][]byte) (value []byte, err error) {
+	if *root_hash == *empty_root && len(proof) == 0 {
+		return
+	}
+	nodes := make(map[common.Hash][]byte, len(proof))
+	for _, node_enc := range proof {
+		nodes[*keccak256.Hash(node_enc)] = node_enc
+	}
+	var kbuf hex_key
+	keybytes_to_hex(key[:], kbuf[:])
+	node_enc, present := nodes[*root_hash]
+	if !present {
+		return nil, ErrProofNodeMissing
+	}
+	for pos := 0; ; {
+		elems, _, err := rlp.SplitList(node_enc)
+		if err != nil {
+			return nil, ErrProofNodeInvalid
+		}
+		var ref []byte
+		switch cnt, _ := rlp.CountValues(elems); cnt {
+		case 2:
+			key_part, rest, err := rlp.SplitString(elems)
+			if err != nil || len(key_part) == 0 {
+				return nil, ErrProofNodeInvalid
+			}
+			key_part = compact_to_hex(key_part)
+			if prefixLen(kbuf[pos:], key_part) != len(key_part) {
+				return nil, nil
+			}
+			if pos += len(key_part); hasTerm(key_part) {
+				if value, _, err = rlp.SplitString(rest); err != nil {
+					return nil, ErrProofNodeInvalid
+				}
+				return value, nil
+			}
+			ref = rest
+		case full_node_child_cnt + 1:
+			if pos >= MaxDepth {
+				return nil, ErrProofNodeInvalid
+			}
+			ref = elems
+			for i := byte(0); i < kbuf[pos]; i++ {
+				if _, _, ref, err = rlp.Split(ref); err != nil {
+					return nil, ErrProofNodeInvalid
+				}
+			}
+			pos++
+		default:
+			return nil, ErrProofNodeInvalid
+		}
+		kind, content, rest, err := rlp.Split(ref)
+		if err != nil {
+			return nil, ErrProofNodeInvalid
+		}
+		switch {
+		case kind == rlp.List:
+			node_enc = ref[:len(ref)-len(rest)]
+		case kind == rlp.String && len(content) == 0:
+			return nil, nil
+		case kind == rlp.String && len(content) == common.HashLength:
+			if node_enc, present = nodes[common.BytesToHash(content)]; !present {
+				return nil, ErrProofNodeMissing
+			}
+		default:
+			return nil, ErrProofNodeInvalid
+		}
+	}
+}
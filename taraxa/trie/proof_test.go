@@ -0,0 +1,107 @@
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+)
+
+type test_schema struct{}
+
+func (test_schema) ValueStorageToHashEncoding(enc_storage []byte) []byte {
+	return rlp.ToRLPStringSimple(enc_storage)
+}
+
+func (test_schema) MaxValueSizeToStoreInTrie() int { return 8 }
+
+type test_value []byte
+
+func (self test_value) EncodeForTrie() (enc_storage, enc_hash []byte) {
+	return self, rlp.ToRLPStringSimple(self)
+}
+
+type test_io struct {
+	values map[common.Hash][]byte
+	nodes  map[common.Hash][]byte
+}
+
+func (self test_io) GetValue(k *common.Hash, cb func([]byte)) {
+	if v, present := self.values[*k]; present && v != nil {
+		cb(v)
+	}
+}
+
+func (self test_io) GetNode(k *common.Hash, cb func([]byte)) {
+	if v, present := self.nodes[*k]; present {
+		cb(v)
+	}
+}
+
+func (self test_io) PutValue(k *common.Hash, v []byte) { self.values[*k] = v }
+func (self test_io) PutNode(k *common.Hash, v []byte)  { self.nodes[*k] = common.CopyBytes(v) }
+
+func TestProof(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	random_hash := func() (ret common.Hash) {
+		rnd.Read(ret[:])
+		return
+	}
+	for _, size := range []int{1, 2, 16, 1000} {
+		io := test_io{make(map[common.Hash][]byte), make(map[common.Hash][]byte)}
+		var writer Writer
+		writer.Init(test_schema{}, nil, WriterOpts{})
+		kv := make(map[common.Hash][]byte, size)
+		for i := 0; i < size; i++ {
+			// both values stored in the nodes and separately
+			v := make([]byte, 1+rnd.Intn(40))
+			rnd.Read(v)
+			k := random_hash()
+			kv[k] = v
+			writer.Put(io, &k, test_value(v))
+		}
+		root := writer.Commit(io)
+
+		reader := Reader{test_schema{}}
+		for k, v := range kv {
+			proof, value := reader.Prove(io, root, &k)
+			if enc_storage, _ := value.EncodeForTrie(); !bytes.Equal(enc_storage, v) {
+				t.Fatalf("wrong value %x for key %x, want %x", enc_storage, k, v)
+			}
+			verified, err := VerifyProof(root, &k, proof)
+			if err != nil {
+				t.Fatalf("proof of key %x is not verified: %v", k, err)
+			}
+			if !bytes.Equal(verified, rlp.ToRLPStringSimple(v)) {
+				t.Fatalf("wrong verified value %x for key %x", verified, k)
+			}
+			// proof of another root doesn't pass
+			if _, err := VerifyProof(&k, &k, proof); err != ErrProofNodeMissing {
+				t.Fatalf("proof of key %x is verified against wrong root", k)
+			}
+		}
+		for i := 0; i < 10; i++ {
+			k := random_hash()
+			proof, value := reader.Prove(io, root, &k)
+			if value != nil {
+				t.Fatalf("absent key %x has value", k)
+			}
+			if verified, err := VerifyProof(root, &k, proof); err != nil || verified != nil {
+				t.Fatalf("absence of key %x is not verified: %v", k, err)
+			}
+		}
+	}
+}
+
+func TestProofEmptyTrie(t *testing.T) {
+	k := common.Hash{1}
+	proof, value := Reader{test_schema{}}.Prove(test_io{}, empty_root, &k)
+	if proof != nil || value != nil {
+		t.Fatal("empty trie proof is not empty")
+	}
+	if verified, err := VerifyProof(empty_root, &k, proof); err != nil || verified != nil {
+		t.Fatalf("empty trie proof is not verified: %v", err)
+	}
+}
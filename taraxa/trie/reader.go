@@ -1,6 +1,8 @@
 package trie
 
 import (
+	"bytes"
+
 	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/rlp"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
@@ -16,11 +18,84 @@ func (self Reader) ForEach(db_tx Input, root_hash *common.Hash, with_values bool
 	self.for_each(db_tx, (*node_hash)(root_hash), with_values, cb, kbuf[:0])
 }
 
+// ForEachFrom is ForEach over the keys which are not less than start, in the order of the keys. The walk stops if cb
+// returns false
+func (self Reader) ForEachFrom(db_tx Input, root_hash, start *common.Hash, with_values bool, cb func(*common.Hash, Value) bool) {
+	var kbuf, start_hex hex_key
+	keybytes_to_hex(start[:], start_hex[:])
+	self.for_each_from(db_tx, (*node_hash)(root_hash), start_hex[:MaxDepth], with_values, cb, kbuf[:0])
+}
+
 func (self Reader) ForEachNodeHash(db_tx Input, root_hash *common.Hash, cb func(*common.Hash, []byte)) {
 	var kbuf hex_key
 	self.for_each_node_hash(db_tx, (*node_hash)(root_hash), cb, kbuf[:0])
 }
 
+// ForEachNodeHashPostOrder visits the nodes stored by hash, children before their parents, so a visited node means
+// that its whole subtree is visited. Subtrees of the nodes skip returns true for are not visited. Values are not read.
+// The walk stops if cb returns false, then the result is false
+func ForEachNodeHashPostOrder(db_tx Input, hash *common.Hash, skip func(*common.Hash) bool, cb func(*common.Hash) bool) bool {
+	if skip(hash) {
+		return true
+	}
+	var enc []byte
+	db_tx.GetNode(hash, func(bytes []byte) {
+		enc = common.CopyBytes(bytes)
+	})
+	asserts.Holds(enc != nil)
+	finished := true
+	for_each_child_hash(enc, func(child *common.Hash) bool {
+		finished = ForEachNodeHashPostOrder(db_tx, child, skip, cb)
+		return finished
+	})
+	return finished && cb(hash)
+}
+
+// for_each_child_hash iterates over the children of the node in the storage encoding which are stored by hash
+func for_each_child_hash(enc []byte, cb func(*common.Hash) bool) bool {
+	elems, _, err := rlp.SplitList(enc)
+	util.PanicIfNotNil(err)
+	cnt, err := rlp.CountValues(elems)
+	util.PanicIfNotNil(err)
+	switch cnt {
+	case 1:
+		// leaf with the value stored separately
+		return true
+	case 2:
+		key_part, rest, err := rlp.SplitString(elems)
+		util.PanicIfNotNil(err)
+		if hasTerm(compact_to_hex(key_part)) {
+			return true
+		}
+		return for_each_child_ref(rest, cb)
+	case full_node_child_cnt:
+		for rest := elems; len(rest) != 0; {
+			_, _, next, err := rlp.Split(rest)
+			util.PanicIfNotNil(err)
+			if !for_each_child_ref(rest[:len(rest)-len(next)], cb) {
+				return false
+			}
+			rest = next
+		}
+		return true
+	default:
+		panic("impossible")
+	}
+}
+
+func for_each_child_ref(ref []byte, cb func(*common.Hash) bool) bool {
+	kind, content, _, err := rlp.Split(ref)
+	util.PanicIfNotNil(err)
+	if kind == rlp.List {
+		return for_each_child_hash(ref, cb)
+	}
+	if len(content) == common.HashLength {
+		hash := common.BytesToHash(content)
+		return cb(&hash)
+	}
+	return true
+}
+
 func (self Reader) for_each_node_hash(db_tx Input, n node, cb func(*common.Hash, []byte), prefix []byte) {
 	switch n := n.(type) {
 	case *node_hash:
@@ -71,6 +146,62 @@ func (self Reader) for_each(db_tx Input, n node, with_values bool, cb KVCallback
 	}
 }
 
+// for_each_from walks the subtree with the key prefix. start is the rest of the start key after the prefix, it is nil
+// if the whole subtree is after the start key
+func (self Reader) for_each_from(
+	db_tx Input, n node, start []byte, with_values bool, cb func(*common.Hash, Value) bool, prefix []byte,
+) bool {
+	switch n := n.(type) {
+	case *node_hash:
+		ret_node, _ := self.resolve(db_tx, n, prefix)
+		return self.for_each_from(db_tx, ret_node, start, with_values, cb, prefix)
+	case *short_node:
+		if start != nil {
+			key_part := n.key_part
+			if hasTerm(key_part) {
+				key_part = key_part[:len(key_part)-1]
+			}
+			switch bytes.Compare(key_part, start[:len(key_part)]) {
+			case -1:
+				return true
+			case 1:
+				start = nil
+			default:
+				start = start[len(key_part):]
+			}
+		}
+		key_extended := append(prefix, n.key_part...)
+		val_n, has_val := n.val.(value_node)
+		if !has_val {
+			return self.for_each_from(db_tx, n.val, start, with_values, cb, key_extended)
+		}
+		var key common.Hash
+		hex_to_keybytes(key_extended, key[:])
+		if val_n == nil_val_node && with_values {
+			val_n = self.resolve_val_n(db_tx, &key)
+		}
+		return cb(&key, val_n.val)
+	case *full_node:
+		for i := byte(0); i < full_node_child_cnt; i++ {
+			child_start := start
+			if start != nil {
+				if i < start[0] {
+					continue
+				}
+				if child_start = start[1:]; start[0] < i {
+					child_start = nil
+				}
+			}
+			if c := n.children[i]; c != nil && !self.for_each_from(db_tx, c, child_start, with_values, cb, append(prefix, i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		panic("impossible")
+	}
+}
+
 func (self Reader) resolve(db_tx Input, hash *node_hash, key_prefix []byte) (ret node, ret_bytes []byte) {
 	db_tx.GetNode(hash.common_hash(), func(bytes []byte) {
 		ret, _ = self.dec_node(db_tx, key_prefix, hash, bytes)
@@ -0,0 +1,120 @@
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+)
+
+func TestForEachNodeHashPostOrder(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	io := test_io{make(map[common.Hash][]byte), make(map[common.Hash][]byte)}
+	var writer Writer
+	writer.Init(test_schema{}, nil, WriterOpts{})
+	for i := 0; i < 1000; i++ {
+		var k common.Hash
+		rnd.Read(k[:])
+		v := make([]byte, 1+rnd.Intn(40))
+		rnd.Read(v)
+		writer.Put(io, &k, test_value(v))
+	}
+	root := writer.Commit(io)
+
+	expected := make(map[common.Hash]bool)
+	Reader{test_schema{}}.ForEachNodeHash(io, root, func(h *common.Hash, _ []byte) {
+		expected[*h] = true
+	})
+	visited := make(map[common.Hash]bool)
+	no_skip := func(*common.Hash) bool { return false }
+	finished := ForEachNodeHashPostOrder(io, root, no_skip, func(h *common.Hash) bool {
+		// children are visited first
+		for_each_child_hash(io.nodes[*h], func(child *common.Hash) bool {
+			if !visited[*child] {
+				t.Fatalf("node %x is visited before its child %x", *h, *child)
+			}
+			return true
+		})
+		visited[*h] = true
+		return true
+	})
+	if !finished || len(visited) != len(expected) {
+		t.Fatalf("visited %d nodes out of %d", len(visited), len(expected))
+	}
+	for h := range expected {
+		if !visited[h] {
+			t.Fatalf("node %x is not visited", h)
+		}
+	}
+
+	// skipped subtrees are not visited, the walk is stopped by the callback
+	visited_cnt := 0
+	skip_all_but_root := func(h *common.Hash) bool { return *h != *root }
+	ForEachNodeHashPostOrder(io, root, skip_all_but_root, func(h *common.Hash) bool {
+		visited_cnt++
+		return true
+	})
+	if visited_cnt != 1 {
+		t.Fatalf("visited %d nodes, expected only the root", visited_cnt)
+	}
+	visited_cnt = 0
+	finished = ForEachNodeHashPostOrder(io, root, no_skip, func(h *common.Hash) bool {
+		visited_cnt++
+		return visited_cnt < 10
+	})
+	if finished || visited_cnt != 10 {
+		t.Fatalf("walk is not stopped, visited %d nodes", visited_cnt)
+	}
+}
+
+func TestForEachFrom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	io := test_io{make(map[common.Hash][]byte), make(map[common.Hash][]byte)}
+	var writer Writer
+	writer.Init(test_schema{}, nil, WriterOpts{})
+	values := make(map[common.Hash][]byte)
+	var keys []common.Hash
+	for i := 0; i < 1000; i++ {
+		var k common.Hash
+		rnd.Read(k[:])
+		v := make([]byte, 1+rnd.Intn(40))
+		rnd.Read(v)
+		writer.Put(io, &k, test_value(v))
+		values[k] = v
+		keys = append(keys, k)
+	}
+	root := writer.Commit(io)
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+
+	starts := []common.Hash{{}, keys[0], keys[500], keys[len(keys)-1], {0xff, 0xff, 0xff}}
+	// between the neighbouring keys
+	starts = append(starts, keys[100])
+	starts[len(starts)-1][common.HashLength-1]++
+	for _, start := range starts {
+		first := sort.Search(len(keys), func(i int) bool { return bytes.Compare(keys[i][:], start[:]) >= 0 })
+		for _, limit := range []int{1, 10, len(keys)} {
+			var visited []common.Hash
+			Reader{test_schema{}}.ForEachFrom(io, root, &start, true, func(k *common.Hash, v Value) bool {
+				if enc_storage, _ := v.EncodeForTrie(); !bytes.Equal(enc_storage, values[*k]) {
+					t.Fatalf("value of %x", *k)
+				}
+				visited = append(visited, *k)
+				return len(visited) < limit
+			})
+			expected := keys[first:]
+			if limit < len(expected) {
+				expected = expected[:limit]
+			}
+			if len(visited) != len(expected) {
+				t.Fatalf("start %x, limit %d: visited %d keys, expected %d", start, limit, len(visited), len(expected))
+			}
+			for i := range expected {
+				if visited[i] != expected[i] {
+					t.Fatalf("start %x: key %d is %x, expected %x", start, i, visited[i], expected[i])
+				}
+			}
+		}
+	}
+}
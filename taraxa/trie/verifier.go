@@ -0,0 +1,199 @@
+package trie
+
+import (
+	"github.com/Taraxa-project/taraxa-evm/common"
+	"github.com/Taraxa-project/taraxa-evm/rlp"
+	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
+)
+
+type VerifyIssueKind = uint8
+
+const (
+	VerifyMissingNode VerifyIssueKind = iota
+	VerifyCorruptedNode
+	VerifyMissingValue
+)
+
+type VerifyIssue struct {
+	Kind VerifyIssueKind
+	// Hash of the node for the node issues, key of the value for the value issues
+	Key common.Hash
+}
+
+// Verifier walks the trie and checks that the nodes stored by hash and the values stored separately exist and that
+// the hashes of the nodes match their content. Subtrees of the missing and corrupted nodes are not walked
+type Verifier struct {
+	Reader
+	// Without the values the hashes of the nodes which include values stored separately are not verified
+	WithValues bool
+	OnValue    func(key *common.Hash, enc_storage []byte)
+	OnIssue    func(VerifyIssue)
+	// Numbers of the nodes stored by hash which hashes are verified and which are walked without the verification
+	NodesVerified    uint64
+	NodesNotVerified uint64
+	enc              hash_encoder
+}
+
+type verifier_item struct {
+	key         common.Hash
+	key_prefix  []byte
+	enc_storage []byte
+}
+
+func (self *Verifier) Verify(db_tx Input, root_hash *common.Hash) {
+	self.verify_node(db_tx, root_hash, nil)
+}
+
+func (self *Verifier) verify_node(db_tx Input, hash *common.Hash, key_prefix []byte) {
+	var enc []byte
+	db_tx.GetNode(hash, func(bytes []byte) {
+		enc = common.CopyBytes(bytes)
+	})
+	if enc == nil {
+		self.OnIssue(VerifyIssue{VerifyMissingNode, *hash})
+		return
+	}
+	// children and values are visited after their node is verified
+	var children, values []verifier_item
+	complete := true
+	var computed *node_hash
+	self.enc.Reset()
+	ok := self.hash_enc(db_tx, enc, key_prefix, true, &computed, &children, &values, &complete)
+	if !ok || complete && (computed == nil || *computed.common_hash() != *hash) {
+		self.OnIssue(VerifyIssue{VerifyCorruptedNode, *hash})
+		return
+	}
+	if complete {
+		self.NodesVerified++
+	} else {
+		self.NodesNotVerified++
+	}
+	if self.OnValue != nil {
+		for i := range values {
+			self.OnValue(&values[i].key, values[i].enc_storage)
+		}
+	}
+	for i := range children {
+		self.verify_node(db_tx, &children[i].key, children[i].key_prefix)
+	}
+}
+
+// hash_enc appends the hash encoding of the node in the storage encoding ref, the same way as Writer.commit does
+func (self *Verifier) hash_enc(
+	db_tx Input, ref, key_prefix []byte, is_root bool, out **node_hash, children, values *[]verifier_item, complete *bool,
+) bool {
+	kind, content, rest, err := rlp.Split(ref)
+	if err != nil || len(rest) != 0 {
+		return false
+	}
+	if kind == rlp.String {
+		switch len(content) {
+		case 0:
+			self.enc.AppendString(nil)
+		case common.HashLength:
+			self.enc.AppendString(content)
+			*children = append(*children, verifier_item{key: common.BytesToHash(content), key_prefix: key_prefix})
+		default:
+			return false
+		}
+		return true
+	}
+	cnt, err := rlp.CountValues(content)
+	if err != nil {
+		return false
+	}
+	if cnt == full_node_child_cnt {
+		list_start := self.enc.ListStart()
+		for i := byte(0); i < full_node_child_cnt; i++ {
+			_, _, next, err := rlp.Split(content)
+			if err != nil {
+				return false
+			}
+			var child_hash *node_hash
+			child_prefix := append(common.CopyBytes(key_prefix), i)
+			if !self.hash_enc(db_tx, content[:len(content)-len(next)], child_prefix, false, &child_hash, children, values, complete) {
+				return false
+			}
+			content = next
+		}
+		self.enc.AppendString(nil)
+		self.enc.ListEnd(list_start, is_root, out)
+		return true
+	}
+	if cnt != 1 && cnt != 2 {
+		return false
+	}
+	key_compact, content, err := rlp.SplitString(content)
+	if err != nil || len(key_compact) == 0 || key_compact[0]>>4 > 3 {
+		return false
+	}
+	key_ext := append(common.CopyBytes(key_prefix), compact_to_hex(key_compact)...)
+	if !hasTerm(key_ext) {
+		if cnt != 2 {
+			return false
+		}
+		list_start := self.enc.ListStart()
+		self.enc.AppendString(key_compact)
+		var child_hash *node_hash
+		if !self.hash_enc(db_tx, content, key_ext, false, &child_hash, children, values, complete) {
+			return false
+		}
+		self.enc.ListEnd(list_start, is_root, out)
+		return true
+	}
+	if len(key_ext) != 2*common.HashLength+1 {
+		return false
+	}
+	var key common.Hash
+	hex_to_keybytes(key_ext, key[:])
+	var val []byte
+	if cnt == 2 {
+		if val, _, err = rlp.SplitString(content); err != nil {
+			return false
+		}
+	}
+	var leaf_hash *common.Hash
+	if len(val) == common.HashLength {
+		leaf_hash, val = new(common.Hash).SetBytes(val), nil
+	} else if cnt == 2 && (len(val) == 0 || self.MaxValueSizeToStoreInTrie() < len(val)) {
+		return false
+	}
+	if val == nil && self.WithValues {
+		db_tx.GetValue(&key, func(bytes []byte) {
+			val = common.CopyBytes(bytes)
+		})
+		if val == nil {
+			self.OnIssue(VerifyIssue{VerifyMissingValue, key})
+		}
+	}
+	if val == nil {
+		// the hash of the leaf is taken as is, the hash of the leaf with the value stored separately is unknown
+		*complete = *complete && leaf_hash != nil && !is_root
+		if leaf_hash != nil {
+			self.enc.AppendString(leaf_hash[:])
+			*out = (*node_hash)(leaf_hash)
+		} else {
+			self.enc.AppendString(nil)
+		}
+		return true
+	}
+	val_enc_hash, ok := self.value_hash_enc(val)
+	if !ok {
+		return false
+	}
+	*values = append(*values, verifier_item{key: key, enc_storage: val})
+	list_start := self.enc.ListStart()
+	self.enc.AppendString(key_compact)
+	self.enc.AppendString(val_enc_hash)
+	var computed *node_hash
+	self.enc.ListEnd(list_start, is_root, &computed)
+	*out = computed
+	return leaf_hash == nil || computed != nil && *computed.common_hash() == *leaf_hash
+}
+
+func (self *Verifier) value_hash_enc(enc_storage []byte) (ret []byte, ok bool) {
+	defer util.Recover(func(util.Any) {
+		ok = false
+	})
+	return self.ValueStorageToHashEncoding(enc_storage), true
+}
@@ -0,0 +1,87 @@
+package trie
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+)
+
+func TestVerifier(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	new_trie := func(size int) (test_io, *common.Hash, map[common.Hash][]byte) {
+		io := test_io{make(map[common.Hash][]byte), make(map[common.Hash][]byte)}
+		kv := make(map[common.Hash][]byte)
+		var writer Writer
+		writer.Init(test_schema{}, nil, WriterOpts{})
+		for i := 0; i < size; i++ {
+			var k common.Hash
+			rnd.Read(k[:])
+			// values stored in the trie and separately
+			v := make([]byte, 1+rnd.Intn(40))
+			rnd.Read(v)
+			writer.Put(io, &k, test_value(v))
+			kv[k] = v
+		}
+		return io, writer.Commit(io), kv
+	}
+	verify := func(io test_io, root *common.Hash, with_values bool) (issues []VerifyIssue, values map[common.Hash][]byte, v *Verifier) {
+		values = make(map[common.Hash][]byte)
+		v = &Verifier{Reader: Reader{test_schema{}}, WithValues: with_values}
+		v.OnValue = func(k *common.Hash, enc_storage []byte) { values[*k] = enc_storage }
+		v.OnIssue = func(issue VerifyIssue) { issues = append(issues, issue) }
+		v.Verify(io, root)
+		return
+	}
+
+	for _, size := range []int{1, 2, 1000} {
+		io, root, kv := new_trie(size)
+		issues, values, v := verify(io, root, true)
+		if len(issues) != 0 || int(v.NodesVerified) != len(io.nodes) || v.NodesNotVerified != 0 {
+			t.Fatalf("trie of %d keys: issues %v, verified %d nodes out of %d", size, issues, v.NodesVerified, len(io.nodes))
+		}
+		if len(values) != len(kv) {
+			t.Fatalf("trie of %d keys: visited %d values", size, len(values))
+		}
+		for k, val := range kv {
+			if string(values[k]) != string(val) {
+				t.Fatalf("trie of %d keys: value %x, expected %x", size, values[k], val)
+			}
+		}
+	}
+
+	io, root, _ := new_trie(1000)
+	var removed_value common.Hash
+	for removed_value = range io.values {
+		break
+	}
+	delete(io.values, removed_value)
+	if issues, _, _ := verify(io, root, true); len(issues) != 1 || issues[0] != (VerifyIssue{VerifyMissingValue, removed_value}) {
+		t.Fatalf("issues of the missing value %v", issues)
+	}
+	// values are not read
+	if issues, _, v := verify(io, root, false); len(issues) != 0 || v.NodesVerified == 0 {
+		t.Fatalf("issues %v without values, %d nodes verified", issues, v.NodesVerified)
+	}
+
+	io, root, _ = new_trie(1000)
+	var corrupted, removed common.Hash
+	for h := range io.nodes {
+		if h == *root {
+			continue
+		}
+		if corrupted == (common.Hash{}) {
+			corrupted = h
+		} else {
+			removed = h
+			break
+		}
+	}
+	io.nodes[corrupted] = append(common.CopyBytes(io.nodes[corrupted][:len(io.nodes[corrupted])-1]), io.nodes[corrupted][len(io.nodes[corrupted])-1]^1)
+	delete(io.nodes, removed)
+	issues, _, _ := verify(io, root, true)
+	expected := map[VerifyIssue]bool{{VerifyCorruptedNode, corrupted}: true, {VerifyMissingNode, removed}: true}
+	if len(issues) != 2 || !expected[issues[0]] || !expected[issues[1]] {
+		t.Fatalf("issues of the corrupted and missing nodes %v", issues)
+	}
+}
@@ -2,6 +2,7 @@ package trie
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/Taraxa-project/taraxa-evm/common"
 	"github.com/Taraxa-project/taraxa-evm/taraxa/util"
@@ -16,11 +17,19 @@ type Writer struct {
 	kbuf_1     hex_key
 	commit_ctx commit_context
 	opts       WriterOpts
+	// number of the writes since the last commit
+	dirty_cnt uint32
 }
 type WriterOpts struct {
 	FullNodeLevelsToCache byte
+	// Number of the writes since the last commit starting from which the subtries are committed concurrently,
+	// 0 means that the commit is always sequential
+	ParallelCommitThreshold uint32 `rlp:"optional"`
 }
 
+// Subtries of the full nodes on these levels are committed concurrently
+const parallel_commit_full_node_levels = 2
+
 func (self *Writer) Init(schema Schema, root_hash *common.Hash, opts WriterOpts) *Writer {
 	asserts.Holds(opts.FullNodeLevelsToCache <= MaxDepth)
 	self.Schema = schema
@@ -32,6 +41,8 @@ func (self *Writer) Init(schema Schema, root_hash *common.Hash, opts WriterOpts)
 }
 
 func (self *Writer) Commit(db_tx IO) *common.Hash {
+	dirty_cnt := self.dirty_cnt
+	self.dirty_cnt = 0
 	if self.root == nil {
 		return nil
 	}
@@ -39,11 +50,11 @@ func (self *Writer) Commit(db_tx IO) *common.Hash {
 		return h.common_hash()
 	}
 	self.commit_ctx.Reset()
+	self.commit_ctx.parallel = self.opts.ParallelCommitThreshold != 0 && self.opts.ParallelCommitThreshold <= dirty_cnt
 	self.root = self.commit(db_tx, &self.commit_ctx, 0, self.kbuf_0[:0], self.root)
 	return self.root.get_hash().common_hash()
 }
 
-// TODO parallel
 func (self *Writer) commit(db_tx IO, ctx *commit_context, full_nodes_above byte, key_prefix []byte, n node) node {
 	is_root := len(key_prefix) == 0
 	switch n := n.(type) {
@@ -103,12 +114,16 @@ func (self *Writer) commit(db_tx IO, ctx *commit_context, full_nodes_above byte,
 			return n
 		}
 		hash_list_start, storage_list_start := ctx.enc_hash.ListStart(), ctx.enc_storage.ListStart()
-		for i := byte(0); i < full_node_child_cnt; i++ {
-			if child := n.children[i]; child != nil {
-				n.children[i] = self.commit(db_tx, ctx, full_nodes_above+1, append(key_prefix, i), child)
-			} else {
-				ctx.enc_hash.AppendString(nil)
-				ctx.enc_storage.AppendString(nil)
+		if ctx.parallel && full_nodes_above < parallel_commit_full_node_levels {
+			self.commit_children_parallel(db_tx, ctx, full_nodes_above, key_prefix, n)
+		} else {
+			for i := byte(0); i < full_node_child_cnt; i++ {
+				if child := n.children[i]; child != nil {
+					n.children[i] = self.commit(db_tx, ctx, full_nodes_above+1, append(key_prefix, i), child)
+				} else {
+					ctx.enc_hash.AppendString(nil)
+					ctx.enc_storage.AppendString(nil)
+				}
 			}
 		}
 		ctx.enc_storage.ListEnd(storage_list_start)
@@ -130,6 +145,55 @@ func (self *Writer) commit(db_tx IO, ctx *commit_context, full_nodes_above byte,
 	}
 }
 
+type subtrie_commit struct {
+	ctx            commit_context
+	writes         write_buffer
+	key_prefix_buf hex_key
+	child          node
+}
+
+// commit_children_parallel commits the children of the full node concurrently. Every child is encoded in its own
+// context and its writes are buffered, then they are appended in the same order as by the sequential commit
+func (self *Writer) commit_children_parallel(db_tx IO, ctx *commit_context, full_nodes_above byte, key_prefix []byte, n *full_node) {
+	var subtries [full_node_child_cnt]*subtrie_commit
+	var wg sync.WaitGroup
+	for i, child := range n.children {
+		if child == nil {
+			continue
+		}
+		subtrie := &subtrie_commit{writes: write_buffer{Input: db_tx}}
+		subtrie.ctx.parallel = true
+		subtrie.ctx.enc_hash.disabled = ctx.enc_hash.disabled
+		key_prefix := append(append(subtrie.key_prefix_buf[:0], key_prefix...), byte(i))
+		subtries[i] = subtrie
+		commit := func() {
+			subtrie.child = self.commit(&subtrie.writes, &subtrie.ctx, full_nodes_above+1, key_prefix, child)
+		}
+		// committed children are only appended
+		if child.get_hash() != nil {
+			commit()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			commit()
+		}()
+	}
+	wg.Wait()
+	for i, subtrie := range subtries {
+		if subtrie == nil {
+			ctx.enc_hash.AppendString(nil)
+			ctx.enc_storage.AppendString(nil)
+			continue
+		}
+		n.children[i] = subtrie.child
+		ctx.enc_hash.AppendRaw(subtrie.ctx.enc_hash.encoder.ToBytes(-1))
+		ctx.enc_storage.AppendRaw(subtrie.ctx.enc_storage.ToBytes(-1)...)
+		subtrie.writes.flush(db_tx)
+	}
+}
+
 func (self *Writer) Put(db_tx IO, k *common.Hash, v Value) {
 	self.write(db_tx, k, value_node{v})
 }
@@ -139,6 +203,7 @@ func (self *Writer) Delete(db_tx IO, k *common.Hash) {
 }
 
 func (self *Writer) write(db_tx IO, k *common.Hash, v value_node) {
+	self.dirty_cnt++
 	keybytes_to_hex(k[:], self.kbuf_0[:])
 	if v != nil_val_node {
 		self.root = self.mpt_insert(db_tx, self.root, 0, v)
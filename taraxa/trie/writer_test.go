@@ -0,0 +1,70 @@
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/Taraxa-project/taraxa-evm/common"
+)
+
+// recording_io keeps the order of the writes
+type recording_io struct {
+	test_io
+	writes *[][]byte
+}
+
+func (self recording_io) PutValue(k *common.Hash, v []byte) {
+	*self.writes = append(*self.writes, append(append([]byte{0}, k[:]...), v...))
+	self.test_io.PutValue(k, v)
+}
+
+func (self recording_io) PutNode(k *common.Hash, v []byte) {
+	*self.writes = append(*self.writes, append(append([]byte{1}, k[:]...), v...))
+	self.test_io.PutNode(k, v)
+}
+
+func TestParallelCommit(t *testing.T) {
+	new_io := func() recording_io {
+		return recording_io{test_io{make(map[common.Hash][]byte), make(map[common.Hash][]byte)}, new([][]byte)}
+	}
+	var sequential, parallel Writer
+	sequential.Init(test_schema{}, nil, WriterOpts{FullNodeLevelsToCache: 1})
+	parallel.Init(test_schema{}, nil, WriterOpts{FullNodeLevelsToCache: 1, ParallelCommitThreshold: 10})
+	sequential_io, parallel_io := new_io(), new_io()
+	rnd := rand.New(rand.NewSource(1))
+	var keys []common.Hash
+	// the first commits are below the threshold
+	for _, batch_size := range []int{1, 5, 1000, 3, 5000, 2000} {
+		for i := 0; i < batch_size; i++ {
+			var k common.Hash
+			if len(keys) != 0 && rnd.Intn(3) == 0 {
+				k = keys[rnd.Intn(len(keys))]
+			} else {
+				rnd.Read(k[:])
+				keys = append(keys, k)
+			}
+			if rnd.Intn(10) == 0 {
+				sequential.Delete(sequential_io, &k)
+				parallel.Delete(parallel_io, &k)
+				continue
+			}
+			v := make([]byte, 1+rnd.Intn(40))
+			rnd.Read(v)
+			sequential.Put(sequential_io, &k, test_value(v))
+			parallel.Put(parallel_io, &k, test_value(v))
+		}
+		sequential_root, parallel_root := sequential.Commit(sequential_io), parallel.Commit(parallel_io)
+		if (sequential_root == nil) != (parallel_root == nil) || sequential_root != nil && *sequential_root != *parallel_root {
+			t.Fatalf("roots differ: %v %v", sequential_root, parallel_root)
+		}
+		if len(*sequential_io.writes) != len(*parallel_io.writes) {
+			t.Fatalf("%d writes, expected %d", len(*parallel_io.writes), len(*sequential_io.writes))
+		}
+		for i, w := range *sequential_io.writes {
+			if !bytes.Equal(w, (*parallel_io.writes)[i]) {
+				t.Fatalf("write %d differs", i)
+			}
+		}
+	}
+}